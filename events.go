@@ -0,0 +1,121 @@
+package goplugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/event"
+)
+
+// GetEventBus used to get host's event bus, it can be used to subscribe host's own handlers
+func (r *Registry) GetEventBus(host string) (*event.Bus, error) {
+	hostPlugin, err := r.GetHostPluginInstance(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if hostPlugin.events == nil {
+		return nil, errs.ErrEventBusUnavailable
+	}
+
+	return hostPlugin.events, nil
+}
+
+// Publish used to publish an event from the host to all subscribers of given topic
+func (r *Registry) Publish(host, topic string, payload []byte) error {
+	return r.PublishContext(context.Background(), host, topic, payload)
+}
+
+// PublishContext used to publish an event like Publish, waiting for subscribers
+// with full buffer will be stopped once the context done
+func (r *Registry) PublishContext(ctx context.Context, host, topic string, payload []byte) error {
+	bus, err := r.GetEventBus(host)
+	if err != nil {
+		return err
+	}
+
+	return bus.PublishContext(ctx, event.Event{Topic: topic, Payload: payload})
+}
+
+// Subscribe used to subscribe a plugin to given topic, events will be pushed to plugin's
+// event.CommandEvent command.  Topic must be allowed by host's events subscribe permissions
+func (r *Registry) Subscribe(host, plugin, topic string) error {
+	builder, err := r.getHost(host)
+	if err != nil {
+		return err
+	}
+
+	if !builder.CanSubscribe(plugin, topic) {
+		return fmt.Errorf("%w: %s -> %s", errs.ErrEventSubscribeDenied, plugin, topic)
+	}
+
+	bus, err := r.GetEventBus(host)
+	if err != nil {
+		return err
+	}
+
+	return bus.Subscribe(plugin, topic, r.pushEvent(host, plugin))
+}
+
+// Unsubscribe used to unsubscribe a plugin from given topic
+func (r *Registry) Unsubscribe(host, plugin, topic string) error {
+	bus, err := r.GetEventBus(host)
+	if err != nil {
+		return err
+	}
+
+	bus.Unsubscribe(plugin, topic)
+	return nil
+}
+
+// pushEvent used to deliver events by calling plugin's event command, the plugin
+// will be started if it's not running
+func (r *Registry) pushEvent(host, plugin string) event.Deliverer {
+	return func(ctx context.Context, e event.Event) error {
+		payload, err := event.Encode(e)
+		if err != nil {
+			return err
+		}
+
+		p, err := r.GetCaller(host, plugin)
+		if err != nil {
+			return err
+		}
+
+		_, err = p.ExecContext(ctx, event.CommandEvent, payload)
+		return err
+	}
+}
+
+// pluginEvents used to route events published or subscribed by plugins through
+// callback services
+type pluginEvents struct {
+	registry *Registry
+}
+
+func (p *pluginEvents) Publish(ctx context.Context, host, from, topic string, payload []byte) error {
+	builder, err := p.registry.getHost(host)
+	if err != nil {
+		return err
+	}
+
+	if !builder.CanPublish(from, topic) {
+		return fmt.Errorf("%w: %s -> %s", errs.ErrEventPublishDenied, from, topic)
+	}
+
+	bus, err := p.registry.GetEventBus(host)
+	if err != nil {
+		return err
+	}
+
+	return bus.PublishContext(ctx, event.Event{Topic: topic, Source: from, Payload: payload})
+}
+
+func (p *pluginEvents) Subscribe(host, from, topic string) error {
+	return p.registry.Subscribe(host, from, topic)
+}
+
+func (p *pluginEvents) Unsubscribe(host, from, topic string) error {
+	return p.registry.Unsubscribe(host, from, topic)
+}
@@ -1,11 +1,16 @@
 package goplugin
 
 import (
+	"time"
+
 	"github.com/quadroops/goplugin/internal/factory"
+	"github.com/quadroops/goplugin/pkg/callback"
 	"github.com/quadroops/goplugin/pkg/discover"
 	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/event"
 	"github.com/quadroops/goplugin/pkg/host"
 	"github.com/quadroops/goplugin/pkg/process"
+	driverProcess "github.com/quadroops/goplugin/pkg/process/driver"
 )
 
 // WithCustomConfigChecker used to customize config checker, the parameter
@@ -39,6 +44,89 @@ func WithCustomProcess(runner process.Runner, processes process.ProcessesBuilder
 	}
 }
 
+// WithEphemeralTLS used to generate throwaway CA and key pairs each time a plugin launched,
+// plugin's materials will be passed through environment variables and the caller will
+// only trust plugin's exact certificate.  This option only affects default runner
+func WithEphemeralTLS() Option {
+	return func(gp *GoPlugin) {
+		gp.runnerOptions = append(gp.runnerOptions, driverProcess.WithEphemeralTLS())
+	}
+}
+
+// WithHandshake used to wait plugin's launch handshake instead of sleeping for plugin's
+// exec time, plugin must send back given cookie and one of supported protocol versions.
+// This option only affects default runner
+func WithHandshake(opts driverProcess.HandshakeOptions) Option {
+	return func(gp *GoPlugin) {
+		gp.runnerOptions = append(gp.runnerOptions, driverProcess.WithHandshake(opts))
+	}
+}
+
+// WithUnixSocket used to run plugins listening on unix domain socket instead of a tcp
+// port, the socket will be created in given runtime directory.  If dir is empty, a
+// per-host runtime directory will be used.  This option only affects default runner
+func WithUnixSocket(dir string) Option {
+	return func(gp *GoPlugin) {
+		if dir == "" {
+			dir = driverProcess.DefaultSocketDir(gp.hostName)
+		}
+
+		gp.runnerOptions = append(gp.runnerOptions, driverProcess.WithUnixSocket(dir))
+	}
+}
+
+// WithSecretResolver used to resolve plugin's secret references using given scheme,
+// such as "vault:database/password" using "vault" scheme.  Only works with default
+// process instance
+func WithSecretResolver(scheme string, resolver process.SecretResolver) Option {
+	return func(gp *GoPlugin) {
+		gp.runnerOptions = append(gp.runnerOptions, driverProcess.WithSecretResolver(scheme, resolver))
+	}
+}
+
+// RunShim used to apply plugin's resource limits before the plugin executed, host
+// having plugins with resource limits must call it at the beginning of its main.
+// See driver.RunShim
+func RunShim() {
+	driverProcess.RunShim()
+}
+
+// WithCgroup used to put host's plugins having resource limits in their own cgroup v2
+// under given delegated directory.  Only works with default process instance, and
+// host's main must call RunShim
+func WithCgroup(dir string) Option {
+	return func(gp *GoPlugin) {
+		gp.runnerOptions = append(gp.runnerOptions, driverProcess.WithCgroup(dir))
+	}
+}
+
+// WithCallbackServices used to start a callback server on each plugin's launch, so
+// plugins can call host's services using callback.Client.  Each server bound to this
+// host, plugin to plugin calls will be registered to the services on install.  This
+// option only affects default runners
+func WithCallbackServices(services *callback.Services) Option {
+	return func(gp *GoPlugin) {
+		gp.callbacks = services
+		gp.runnerOptions = append(gp.runnerOptions, driverProcess.WithLaunchHook(services.LaunchHost(gp.hostName)))
+	}
+}
+
+// WithEventBus used to create host's event bus, plugins can publish and subscribe
+// events through callback services if the host using WithCallbackServices
+func WithEventBus(opts *event.Options) Option {
+	return func(gp *GoPlugin) {
+		gp.events = event.NewBus(opts)
+	}
+}
+
+// WithGracePeriod used to set how long host's plugins will be waited to exit after
+// asked to stop by KillPlugins, before their process groups killed
+func WithGracePeriod(grace time.Duration) Option {
+	return func(gp *GoPlugin) {
+		gp.gracePeriod = grace
+	}
+}
+
 // Map used to put a plugin and assign it with their spesific configurations
 func Map(pluginName string, conf *PluginConf) PluginMapper {
 	mapper := make(PluginMapper)
@@ -53,14 +141,19 @@ func New(hostName string, opts ...Option) *GoPlugin {
 		hostName:        hostName,
 		configChecker:   factory.DefaultConfigChecker(),
 		configParser:    factory.DefaultConfigParser(),
-		processInstance: factory.DefaultProcessInstance(),
 		identityChecker: factory.DefaultHostIdentityChecker(),
+		gracePeriod:     process.DefaultGracePeriod,
 	}
 
 	for _, option := range opts {
 		option(gp)
 	}
 
+	// custom process may has been set by WithCustomProcess
+	if gp.processInstance == nil {
+		gp.processInstance = factory.DefaultProcessInstance(gp.runnerOptions...)
+	}
+
 	return gp
 }
 
@@ -3,8 +3,9 @@ module github.com/quadroops/goplugin
 go 1.14
 
 require (
-	github.com/cenkalti/backoff/v4 v4.0.2 // indirect
+	github.com/cenkalti/backoff/v4 v4.0.2
 	github.com/golang/protobuf v1.4.1
+	github.com/gorilla/websocket v1.4.2
 	github.com/hashicorp/go-multierror v1.1.0
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/pelletier/go-toml v1.6.0
@@ -13,6 +14,7 @@ require (
 	github.com/stretchr/testify v1.5.1
 	github.com/vmihailenco/msgpack/v5 v5.0.0-beta.1
 	golang.org/x/sys v0.0.0-20190412213103-97732733099d // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.29.1
 	google.golang.org/protobuf v1.24.0
 	gopkg.in/yaml.v2 v2.2.8 // indirect
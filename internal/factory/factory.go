@@ -7,6 +7,7 @@ import (
 	"github.com/quadroops/goplugin/pkg/discover"
 	driverDiscover "github.com/quadroops/goplugin/pkg/discover/driver"
 
+	driverCaller "github.com/quadroops/goplugin/pkg/caller/driver"
 	"github.com/quadroops/goplugin/pkg/process"
 	driverProcess "github.com/quadroops/goplugin/pkg/process/driver"
 )
@@ -27,11 +28,11 @@ func DefaultConfigParser() *discover.ConfigParser {
 }
 
 // DefaultProcessInstance .
-func DefaultProcessInstance() *process.Instance {
-	subprocess := driverProcess.NewSubProcess()
+func DefaultProcessInstance(opts ...driverProcess.SubProcessOption) *process.Instance {
+	subprocess := driverProcess.NewSubProcess(opts...)
 	registry := driverProcess.NewRegistry()
 	processes := driverProcess.NewProcesses(registry)
-	return process.New(subprocess, processes)
+	return process.New(subprocess, processes).RegisterRunner(driverCaller.ProtocolStdio, driverProcess.NewPipeProcess(opts...))
 }
 
 // DefaultHostIdentityChecker .
@@ -0,0 +1,108 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// ServiceLog used as builtin logging service's name
+	ServiceLog = "log"
+
+	// ServiceKVGet used as builtin kv service to get a value, payload is the key
+	ServiceKVGet = "kv.get"
+
+	// ServiceKVSet used as builtin kv service to set a value, payload is KVEntry
+	ServiceKVSet = "kv.set"
+
+	// ServiceKVDelete used as builtin kv service to delete a value, payload is the key
+	ServiceKVDelete = "kv.delete"
+)
+
+// KVEntry used as kv.set's payload
+type KVEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// KV used as in-memory key value store shared by all plugins
+type KV struct {
+	mutex sync.RWMutex
+	data  map[string]string
+}
+
+// NewKV used to create new instance of KV
+func NewKV() *KV {
+	return &KV{data: make(map[string]string)}
+}
+
+// Get used to get a value by its key
+func (kv *KV) Get(key string) (string, bool) {
+	kv.mutex.RLock()
+	defer kv.mutex.RUnlock()
+
+	value, exist := kv.data[key]
+	return value, exist
+}
+
+// Set used to set a value
+func (kv *KV) Set(key, value string) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	kv.data[key] = value
+}
+
+// Delete used to delete a value
+func (kv *KV) Delete(key string) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	delete(kv.data, key)
+}
+
+// WithLog used to register builtin logging service, payload will be logged using
+// given logger prefixed by plugin's name.  Standard logger used if logger is nil
+func (s *Services) WithLog(logger *log.Logger) *Services {
+	if logger == nil {
+		logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+
+	return s.Register(ServiceLog, func(ctx context.Context, payload []byte) ([]byte, error) {
+		logger.Printf("[%s] %s", PluginName(ctx), payload)
+		return nil, nil
+	})
+}
+
+// WithKV used to register builtin kv services using given store
+func (s *Services) WithKV(kv *KV) *Services {
+	s.Register(ServiceKVGet, func(_ context.Context, payload []byte) ([]byte, error) {
+		value, exist := kv.Get(string(payload))
+		if !exist {
+			return nil, fmt.Errorf("%w: %q", errs.ErrCallbackKeyNotFound, payload)
+		}
+
+		return []byte(value), nil
+	})
+
+	s.Register(ServiceKVSet, func(_ context.Context, payload []byte) ([]byte, error) {
+		var entry KVEntry
+		err := json.Unmarshal(payload, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+		}
+
+		kv.Set(entry.Key, entry.Value)
+		return nil, nil
+	})
+
+	return s.Register(ServiceKVDelete, func(_ context.Context, payload []byte) ([]byte, error) {
+		kv.Delete(string(payload))
+		return nil, nil
+	})
+}
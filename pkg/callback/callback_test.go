@@ -0,0 +1,215 @@
+package callback_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/callback"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func launch(t *testing.T, services *callback.Services) *callback.Client {
+	return launchHook(t, services.LaunchHost("host"))
+}
+
+func launchHook(t *testing.T, hook func(name string) ([]string, func(), error)) *callback.Client {
+	env, release, err := hook("test")
+	assert.NoError(t, err)
+	t.Cleanup(release)
+
+	vars := make(map[string]string)
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		vars[kv[0]] = kv[1]
+	}
+
+	return callback.NewClient(vars[callback.EnvAddr], vars[callback.EnvToken])
+}
+
+func TestCallSuccess(t *testing.T) {
+	services := callback.NewServices().Register("echo", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return append([]byte(callback.PluginName(ctx)+":"), payload...), nil
+	})
+
+	client := launch(t, services)
+	resp, err := client.Call(context.Background(), "echo", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test:hello"), resp)
+}
+
+func TestCallUnknownService(t *testing.T) {
+	client := launch(t, callback.NewServices())
+	_, err := client.Call(context.Background(), "unknown", nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackUnknownService))
+}
+
+func TestCallHandlerError(t *testing.T) {
+	services := callback.NewServices().Register("fail", func(_ context.Context, _ []byte) ([]byte, error) {
+		return nil, errors.New("something wrong")
+	})
+
+	client := launch(t, services)
+	_, err := client.Call(context.Background(), "fail", nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackFailed))
+	assert.Contains(t, err.Error(), "something wrong")
+}
+
+func TestCallUnauthorized(t *testing.T) {
+	env, release, err := callback.NewServices().WithLog(nil).Launch("test")
+	assert.NoError(t, err)
+	defer release()
+
+	addr := strings.TrimPrefix(env[0], callback.EnvAddr+"=")
+	client := callback.NewClient(addr, "invalid")
+	_, err = client.Call(context.Background(), callback.ServiceLog, []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackUnauthorized))
+}
+
+func TestCallReleased(t *testing.T) {
+	env, release, err := callback.NewServices().WithLog(nil).Launch("test")
+	assert.NoError(t, err)
+	release()
+
+	addr := strings.TrimPrefix(env[0], callback.EnvAddr+"=")
+	token := strings.TrimPrefix(env[1], callback.EnvToken+"=")
+	_, err = callback.NewClient(addr, token).Call(context.Background(), callback.ServiceLog, []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackRequest))
+}
+
+func TestCallKV(t *testing.T) {
+	kv := callback.NewKV()
+	client := launch(t, callback.NewServices().WithKV(kv))
+
+	entry, _ := json.Marshal(callback.KVEntry{Key: "key", Value: "value"})
+	_, err := client.Call(context.Background(), callback.ServiceKVSet, entry)
+	assert.NoError(t, err)
+
+	value, exist := kv.Get("key")
+	assert.True(t, exist)
+	assert.Equal(t, "value", value)
+
+	resp, err := client.Call(context.Background(), callback.ServiceKVGet, []byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), resp)
+
+	_, err = client.Call(context.Background(), callback.ServiceKVDelete, []byte("key"))
+	assert.NoError(t, err)
+
+	_, err = client.Call(context.Background(), callback.ServiceKVGet, []byte("key"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackFailed))
+}
+
+func TestNewClientFromEnvUnavailable(t *testing.T) {
+	os.Unsetenv(callback.EnvAddr)
+	_, err := callback.NewClientFromEnv()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackUnavailable))
+}
+
+func TestCallPlugin(t *testing.T) {
+	services := callback.NewServices().WithPluginCalls(func(ctx context.Context, host, from, plugin, cmd string, payload []byte) ([]byte, error) {
+		assert.Equal(t, "host", host)
+		assert.Equal(t, "test", from)
+		assert.Equal(t, "plugin_2", plugin)
+		assert.Equal(t, "cmd", cmd)
+		assert.Equal(t, []string{"plugin_1"}, callback.CallChain(ctx))
+		return payload, nil
+	})
+
+	client := launch(t, services)
+	ctx := callback.WithCallChain(context.Background(), []string{"plugin_1"})
+	resp, err := client.CallPlugin(ctx, "host", "plugin_2", "cmd", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), resp)
+}
+
+func TestCallPluginHostMismatch(t *testing.T) {
+	var hosts []string
+	services := callback.NewServices().WithPluginCalls(func(ctx context.Context, host, from, plugin, cmd string, payload []byte) ([]byte, error) {
+		hosts = append(hosts, host)
+		return payload, nil
+	})
+
+	client := launch(t, services)
+	_, err := client.CallPlugin(context.Background(), "other", "plugin_2", "cmd", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCallbackFailed))
+	assert.Contains(t, err.Error(), errs.ErrCallbackHost.Error())
+
+	// empty host means caller plugin's own host
+	_, err = client.CallPlugin(context.Background(), "", "plugin_2", "cmd", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"host"}, hosts)
+
+	// server which is not bound to any host can't route plugin's calls
+	unbound := launchHook(t, services.Launch)
+	_, err = unbound.CallPlugin(context.Background(), "host", "plugin_2", "cmd", []byte("hello"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), errs.ErrCallbackHost.Error())
+	assert.Len(t, hosts, 1)
+}
+
+func TestParseCallChain(t *testing.T) {
+	assert.Nil(t, callback.ParseCallChain(""))
+	assert.Equal(t, []string{"a", "b"}, callback.ParseCallChain(callback.FormatCallChain([]string{"a", "b"})))
+}
+
+type eventRouter struct {
+	published  []string
+	subscribed []string
+}
+
+func (r *eventRouter) Publish(ctx context.Context, host, from, topic string, payload []byte) error {
+	r.published = append(r.published, host+":"+from+":"+topic+":"+string(payload))
+	return nil
+}
+
+func (r *eventRouter) Subscribe(host, from, topic string) error {
+	r.subscribed = append(r.subscribed, host+":"+from+":"+topic)
+	return nil
+}
+
+func (r *eventRouter) Unsubscribe(host, from, topic string) error {
+	return errs.ErrEventBusUnavailable
+}
+
+func TestCallEvents(t *testing.T) {
+	router := &eventRouter{}
+	client := launch(t, callback.NewServices().WithEvents(router))
+
+	assert.NoError(t, client.Publish(context.Background(), "host", "order.created", []byte("hello")))
+	assert.NoError(t, client.Subscribe(context.Background(), "host", "order.*"))
+	assert.Equal(t, []string{"host:test:order.created:hello"}, router.published)
+	assert.Equal(t, []string{"host:test:order.*"}, router.subscribed)
+
+	err := client.Unsubscribe(context.Background(), "host", "order.*")
+	assert.True(t, errors.Is(err, errs.ErrCallbackFailed))
+}
+
+func TestCallEventsHostMismatch(t *testing.T) {
+	router := &eventRouter{}
+	client := launch(t, callback.NewServices().WithEvents(router))
+
+	err := client.Publish(context.Background(), "other", "order.created", []byte("hello"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), errs.ErrCallbackHost.Error())
+
+	err = client.Subscribe(context.Background(), "other", "order.*")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), errs.ErrCallbackHost.Error())
+
+	// empty host means caller plugin's own host
+	assert.NoError(t, client.Publish(context.Background(), "", "order.created", []byte("hello")))
+	assert.Equal(t, []string{"host:test:order.created:hello"}, router.published)
+	assert.Empty(t, router.subscribed)
+}
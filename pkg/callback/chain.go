@@ -0,0 +1,108 @@
+package callback
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// ServicePluginCall used as builtin service to call other plugin's command, payload is PluginCall
+	ServicePluginCall = "plugin.call"
+
+	// HeaderCallChain used to pass call chain to rest plugins
+	HeaderCallChain = "X-Goplugin-Call-Chain"
+
+	// MetadataCallChain used to pass call chain to grpc plugins
+	MetadataCallChain = "goplugin-call-chain"
+)
+
+// PluginCall used as plugin.call's payload, payload encoded in hex.  Host must be
+// empty or the caller plugin's own host.  Chain contains plugins which are waiting
+// for this call, used for loop detection
+type PluginCall struct {
+	Host    string   `json:"host"`
+	Plugin  string   `json:"plugin"`
+	Command string   `json:"command"`
+	Payload string   `json:"payload"`
+	Chain   []string `json:"chain,omitempty"`
+}
+
+// PluginCaller used by the host to route plugin's call to other plugin, from is
+// the caller plugin's name and ctx will contain the call chain
+type PluginCaller func(ctx context.Context, host, from, plugin, cmd string, payload []byte) ([]byte, error)
+
+type chainKey struct{}
+
+// WithCallChain used to put call chain to the context, chain will be sent to the
+// plugin by callers and should be passed back by plugins when calling other plugins
+func WithCallChain(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, chainKey{}, chain)
+}
+
+// CallChain used to get call chain from the context
+func CallChain(ctx context.Context) []string {
+	chain, _ := ctx.Value(chainKey{}).([]string)
+	return chain
+}
+
+// FormatCallChain used to encode call chain as header or metadata's value
+func FormatCallChain(chain []string) string {
+	return strings.Join(chain, ",")
+}
+
+// ParseCallChain used to decode call chain from header or metadata's value
+func ParseCallChain(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// WithPluginCalls used to register builtin plugin.call service, calls will be routed
+// to other plugins of caller plugin's host using given caller
+func (s *Services) WithPluginCalls(call PluginCaller) *Services {
+	return s.Register(ServicePluginCall, func(ctx context.Context, payload []byte) ([]byte, error) {
+		var req PluginCall
+		err := json.Unmarshal(payload, &req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+		}
+
+		host, err := boundHost(ctx, req.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := hex.DecodeString(req.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+		}
+
+		ctx = WithCallChain(ctx, req.Chain)
+		return call(ctx, host, PluginName(ctx), req.Plugin, req.Command, data)
+	})
+}
+
+// CallPlugin used to call other plugin's command through the host.  Call chain
+// from ctx will be sent to the host, so plugins should put chain received from the
+// host using WithCallChain before calling other plugins
+func (c *Client) CallPlugin(ctx context.Context, host, plugin, cmd string, payload []byte) ([]byte, error) {
+	body, err := json.Marshal(PluginCall{
+		Host:    host,
+		Plugin:  plugin,
+		Command: cmd,
+		Payload: hex.EncodeToString(payload),
+		Chain:   CallChain(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	return c.Call(ctx, ServicePluginCall, body)
+}
@@ -0,0 +1,93 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// Client used by plugin to call host's services
+type Client struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewClient used to create new instance of Client for given callback server's
+// address and token
+func NewClient(addr, token string) *Client {
+	return &Client{
+		addr:   addr,
+		token:  token,
+		client: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// NewClientFromEnv used to create new instance of Client from env given by the
+// host on plugin's launch
+func NewClientFromEnv() (*Client, error) {
+	addr := os.Getenv(EnvAddr)
+	if addr == "" {
+		return nil, fmt.Errorf("%w: %s is not defined", errs.ErrCallbackUnavailable, EnvAddr)
+	}
+
+	return NewClient(addr, os.Getenv(EnvToken)), nil
+}
+
+// Call used to call host's service
+func (c *Client) Call(ctx context.Context, service string, payload []byte) ([]byte, error) {
+	body, err := json.Marshal(Request{
+		Service: service,
+		Payload: hex.EncodeToString(payload),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s%s", c.addr, PathCall), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+	defer resp.Body.Close()
+
+	var response Response
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("%w: %s", errs.ErrCallbackUnauthorized, response.Message)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", errs.ErrCallbackUnknownService, response.Message)
+	default:
+		return nil, fmt.Errorf("%w: %s", errs.ErrCallbackFailed, response.Message)
+	}
+
+	if response.Data == nil {
+		return nil, nil
+	}
+
+	data, err := hex.DecodeString(response.Data.Response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,120 @@
+package callback
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// ServiceEventPublish used as builtin service to publish an event, payload is EventMessage
+	ServiceEventPublish = "event.publish"
+
+	// ServiceEventSubscribe used as builtin service to subscribe a topic, payload is EventMessage
+	ServiceEventSubscribe = "event.subscribe"
+
+	// ServiceEventUnsubscribe used as builtin service to unsubscribe a topic, payload is EventMessage
+	ServiceEventUnsubscribe = "event.unsubscribe"
+)
+
+// EventMessage used as event services's payload, payload encoded in hex and only
+// used when publishing an event.  Host must be empty or the caller plugin's own host
+type EventMessage struct {
+	Host    string `json:"host"`
+	Topic   string `json:"topic"`
+	Payload string `json:"payload,omitempty"`
+}
+
+// EventRouter used by the host to route plugin's events, from is the caller plugin's name.
+// Publish's ctx done once the plugin's request cancelled
+type EventRouter interface {
+	Publish(ctx context.Context, host, from, topic string, payload []byte) error
+	Subscribe(host, from, topic string) error
+	Unsubscribe(host, from, topic string) error
+}
+
+// WithEvents used to register builtin event services, events will be routed to caller
+// plugin's host using given router
+func (s *Services) WithEvents(router EventRouter) *Services {
+	s.Register(ServiceEventPublish, func(ctx context.Context, payload []byte) ([]byte, error) {
+		host, msg, data, err := decodeEventMessage(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, router.Publish(ctx, host, PluginName(ctx), msg.Topic, data)
+	})
+
+	s.Register(ServiceEventSubscribe, func(ctx context.Context, payload []byte) ([]byte, error) {
+		host, msg, _, err := decodeEventMessage(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, router.Subscribe(host, PluginName(ctx), msg.Topic)
+	})
+
+	return s.Register(ServiceEventUnsubscribe, func(ctx context.Context, payload []byte) ([]byte, error) {
+		host, msg, _, err := decodeEventMessage(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, router.Unsubscribe(host, PluginName(ctx), msg.Topic)
+	})
+}
+
+// Publish used to publish an event through the host
+func (c *Client) Publish(ctx context.Context, host, topic string, payload []byte) error {
+	return c.callEvent(ctx, ServiceEventPublish, EventMessage{
+		Host:    host,
+		Topic:   topic,
+		Payload: hex.EncodeToString(payload),
+	})
+}
+
+// Subscribe used to subscribe a topic, events will be pushed to plugin's
+// event.CommandEvent command
+func (c *Client) Subscribe(ctx context.Context, host, topic string) error {
+	return c.callEvent(ctx, ServiceEventSubscribe, EventMessage{Host: host, Topic: topic})
+}
+
+// Unsubscribe used to unsubscribe a topic
+func (c *Client) Unsubscribe(ctx context.Context, host, topic string) error {
+	return c.callEvent(ctx, ServiceEventUnsubscribe, EventMessage{Host: host, Topic: topic})
+}
+
+func (c *Client) callEvent(ctx context.Context, service string, msg EventMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	_, err = c.Call(ctx, service, body)
+	return err
+}
+
+// decodeEventMessage used to decode event services's payload, returned host is
+// the host bound to caller plugin's callback server
+func decodeEventMessage(ctx context.Context, payload []byte) (string, EventMessage, []byte, error) {
+	var msg EventMessage
+	err := json.Unmarshal(payload, &msg)
+	if err != nil {
+		return "", msg, nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	host, err := boundHost(ctx, msg.Host)
+	if err != nil {
+		return "", msg, nil, err
+	}
+
+	data, err := hex.DecodeString(msg.Payload)
+	if err != nil {
+		return "", msg, nil, fmt.Errorf("%w: %q", errs.ErrCallbackRequest, err)
+	}
+
+	return host, msg, data, nil
+}
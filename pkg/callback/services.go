@@ -0,0 +1,171 @@
+package callback
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// Services used to store host's services which can be called by plugins.  Each
+// plugin's launch will start its own callback server with its own credential
+type Services struct {
+	mutex    sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewServices used to create new instance of Services
+func NewServices() *Services {
+	return &Services{
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register used to register a service, registering an existing name will
+// replace its handler
+func (s *Services) Register(name string, handler Handler) *Services {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.handlers[name] = handler
+	return s
+}
+
+// Call used to call registered service directly from the host as given plugin
+func (s *Services) Call(ctx context.Context, plugin, service string, payload []byte) ([]byte, error) {
+	return s.call(ctx, "", plugin, service, payload)
+}
+
+// CallHost used to call registered service directly from the host as given
+// plugin of given host
+func (s *Services) CallHost(ctx context.Context, host, plugin, service string, payload []byte) ([]byte, error) {
+	return s.call(ctx, host, plugin, service, payload)
+}
+
+func (s *Services) call(ctx context.Context, host, plugin, service string, payload []byte) ([]byte, error) {
+	s.mutex.RLock()
+	handler, exist := s.handlers[service]
+	s.mutex.RUnlock()
+
+	if !exist {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCallbackUnknownService, service)
+	}
+
+	ctx = context.WithValue(ctx, hostKey{}, host)
+	return handler(context.WithValue(ctx, pluginKey{}, plugin), payload)
+}
+
+// Launch used to start a callback server for given plugin.  Returned env should be
+// passed to the plugin and release should be called after the plugin exited.
+// Launch can be used as process's launch hook, its server not bound to any host so
+// host's scoped services such as plugin.call and events will be rejected
+func (s *Services) Launch(name string) ([]string, func(), error) {
+	return s.launch("", name)
+}
+
+// LaunchHost used to create a launch hook starting callback servers bound to given
+// host, plugins can only use host's scoped services of that host
+func (s *Services) LaunchHost(host string) func(name string) ([]string, func(), error) {
+	return func(name string) ([]string, func(), error) {
+		return s.launch(host, name)
+	}
+}
+
+func (s *Services) launch(host, name string) ([]string, func(), error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %q", errs.ErrCallbackServer, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %q", errs.ErrCallbackServer, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(PathCall, &server{services: s, host: host, plugin: name, token: token})
+
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(listener)
+
+	env := []string{
+		fmt.Sprintf("%s=%s", EnvAddr, listener.Addr().String()),
+		fmt.Sprintf("%s=%s", EnvToken, token),
+	}
+
+	return env, func() { srv.Close() }, nil
+}
+
+type server struct {
+	services *Services
+	host     string
+	plugin   string
+	token    string
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, Response{Status: StatusError, Message: "method not allowed"})
+		return
+	}
+
+	// compared in constant time so the token can't be guessed by response's timing
+	expected := fmt.Sprintf("Bearer %s", srv.token)
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+		writeResponse(w, http.StatusUnauthorized, Response{Status: StatusError, Message: "unauthorized"})
+		return
+	}
+
+	var req Request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, Response{Status: StatusError, Message: err.Error()})
+		return
+	}
+
+	payload, err := hex.DecodeString(req.Payload)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, Response{Status: StatusError, Message: err.Error()})
+		return
+	}
+
+	resp, err := srv.services.call(r.Context(), srv.host, srv.plugin, req.Service, payload)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errs.ErrCallbackUnknownService) {
+			code = http.StatusNotFound
+		}
+
+		writeResponse(w, code, Response{Status: StatusError, Message: err.Error()})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, Response{
+		Status: StatusSuccess,
+		Data:   &ResponseData{Response: hex.EncodeToString(resp)},
+	})
+}
+
+func writeResponse(w http.ResponseWriter, code int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
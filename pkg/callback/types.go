@@ -0,0 +1,85 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// EnvAddr used to pass callback server's address to the plugin
+	EnvAddr = "GOPLUGIN_CALLBACK_ADDR"
+
+	// EnvToken used to pass callback server's credential to the plugin
+	EnvToken = "GOPLUGIN_CALLBACK_TOKEN"
+
+	// PathCall used as callback server's endpoint
+	PathCall = "/call"
+
+	// StatusSuccess used as JSEND's status for success response
+	StatusSuccess = "success"
+
+	// StatusError used as JSEND's status for failed response
+	StatusError = "error"
+
+	// DefaultTimeout used as default client's timeout
+	DefaultTimeout = 5 * time.Second
+)
+
+// Handler used as host's service implementation, ctx will contain the caller
+// plugin's name which can be fetched using PluginName, and its host's name
+// which can be fetched using HostName
+type Handler func(ctx context.Context, payload []byte) ([]byte, error)
+
+// Request used as main payload sent by plugin to the host, payload encoded
+// in hex
+type Request struct {
+	Service string `json:"service"`
+	Payload string `json:"payload"`
+}
+
+// ResponseData used as main response data, response encoded in hex
+type ResponseData struct {
+	Response string `json:"response"`
+}
+
+// Response following JSEND standard as callback server's response
+type Response struct {
+	Status  string        `json:"status"`
+	Data    *ResponseData `json:"data,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+type pluginKey struct{}
+
+type hostKey struct{}
+
+// PluginName used to get caller plugin's name from handler's context
+func PluginName(ctx context.Context) string {
+	name, _ := ctx.Value(pluginKey{}).(string)
+	return name
+}
+
+// HostName used to get caller plugin's host name from handler's context, empty
+// if the callback server not bound to any host
+func HostName(ctx context.Context) string {
+	name, _ := ctx.Value(hostKey{}).(string)
+	return name
+}
+
+// boundHost used to check payload's host against caller plugin's host, an empty
+// payload's host means caller plugin's own host
+func boundHost(ctx context.Context, host string) (string, error) {
+	bound := HostName(ctx)
+	if bound == "" {
+		return "", fmt.Errorf("%w: callback server is not bound to any host", errs.ErrCallbackHost)
+	}
+
+	if host != "" && host != bound {
+		return "", fmt.Errorf("%w: %s", errs.ErrCallbackHost, host)
+	}
+
+	return bound, nil
+}
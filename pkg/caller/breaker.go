@@ -0,0 +1,268 @@
+package caller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// BreakerClosed means all requests will be passed to the plugin
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen means all requests will be rejected until cool-down period reached
+	BreakerOpen
+
+	// BreakerHalfOpen means breaker is sending a probe request to check if plugin has been recovered
+	BreakerHalfOpen
+)
+
+const (
+	// DefaultBreakerWindow used when breaker options doesn't define window
+	DefaultBreakerWindow = 60 * time.Second
+
+	// DefaultBreakerCoolDown used when breaker options doesn't define cool-down period
+	DefaultBreakerCoolDown = 30 * time.Second
+
+	// DefaultBreakerMinRequests used when breaker options doesn't define min requests
+	DefaultBreakerMinRequests = 10
+)
+
+// BreakerState used to describe circuit breaker's state
+type BreakerState int
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// BreakerOptions used to configure circuit breaker.  A breaker will be tripped
+// when one of ConsecutiveFailures or FailureRate threshold reached, zero value
+// means the threshold is disabled
+type BreakerOptions struct {
+	// ConsecutiveFailures used as max consecutive failures before breaker tripped
+	ConsecutiveFailures int
+
+	// FailureRate used as max failure rate (between 0 and 1) within a window
+	FailureRate float64
+
+	// MinRequests used as minimum requests within a window before failure rate evaluated
+	MinRequests int
+
+	// Window used as failure rate's period, counters will be reset after window reached
+	Window time.Duration
+
+	// CoolDown used as waiting time before an open breaker allowed to send a probe
+	CoolDown time.Duration
+}
+
+// Breaker is a circuit breaker for a single plugin
+type Breaker struct {
+	mutex       sync.Mutex
+	opts        BreakerOptions
+	state       BreakerState
+	consecutive int
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	now         func() time.Time
+}
+
+// Breakers used to store circuit breakers based on plugin's name
+type Breakers struct {
+	mutex sync.Mutex
+	data  map[string]*Breaker
+}
+
+// NewBreaker used to create new circuit breaker
+func NewBreaker(opts *BreakerOptions) *Breaker {
+	b := &Breaker{now: time.Now}
+	if opts != nil {
+		b.opts = *opts
+	}
+
+	if b.opts.Window <= 0 {
+		b.opts.Window = DefaultBreakerWindow
+	}
+
+	if b.opts.CoolDown <= 0 {
+		b.opts.CoolDown = DefaultBreakerCoolDown
+	}
+
+	if b.opts.MinRequests < 1 {
+		b.opts.MinRequests = DefaultBreakerMinRequests
+	}
+
+	b.windowStart = b.now()
+	return b
+}
+
+// State used to get current breaker's state
+func (b *Breaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
+
+// Reset used to close the breaker and reset all of its counters
+func (b *Breaker) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.close()
+}
+
+// allow used to check if a request allowed to be sent.  If breaker is open and
+// cool-down period has been reached, it will switch to half-open and ask the
+// caller to send a probe request
+func (b *Breaker) allow() (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.opts.CoolDown {
+			return false, errs.ErrPluginCircuitOpen
+		}
+
+		b.state = BreakerHalfOpen
+		return true, nil
+	case BreakerHalfOpen:
+		// there is another probe in progress
+		return false, errs.ErrPluginCircuitOpen
+	}
+
+	return false, nil
+}
+
+func (b *Breaker) onProbe(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err != nil {
+		b.trip()
+		return
+	}
+
+	b.close()
+}
+
+func (b *Breaker) onResult(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state != BreakerClosed {
+		return
+	}
+
+	now := b.now()
+	if now.Sub(b.windowStart) >= b.opts.Window {
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+	}
+
+	b.requests++
+	if err == nil {
+		b.consecutive = 0
+		return
+	}
+
+	b.failures++
+	b.consecutive++
+
+	if b.opts.ConsecutiveFailures > 0 && b.consecutive >= b.opts.ConsecutiveFailures {
+		b.trip()
+		return
+	}
+
+	if b.opts.FailureRate > 0 && b.requests >= b.opts.MinRequests {
+		if float64(b.failures)/float64(b.requests) >= b.opts.FailureRate {
+			b.trip()
+		}
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+}
+
+func (b *Breaker) close() {
+	b.state = BreakerClosed
+	b.consecutive = 0
+	b.requests = 0
+	b.failures = 0
+	b.windowStart = b.now()
+}
+
+// guard used to check breaker before sending a request to the plugin, when a probe
+// is needed, given probe function will be used to check plugin's health
+func (b *Breaker) guard(ctx context.Context, probe func(ctx context.Context) error) error {
+	isProbe, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	if isProbe {
+		errProbe := probe(ctx)
+		b.onProbe(errProbe)
+		if errProbe != nil {
+			return fmt.Errorf("%w: %q", errs.ErrPluginCircuitOpen, errProbe)
+		}
+	}
+
+	return nil
+}
+
+// record used to save request's result, a request cancelled by its caller
+// will not be counted as a failure
+func (b *Breaker) record(err error) {
+	if err != nil && errors.Is(err, context.Canceled) {
+		return
+	}
+
+	// plugin which is able to return non retryable error is still healthy
+	var pluginErr *errs.PluginError
+	if errors.As(err, &pluginErr) && !pluginErr.Retryable {
+		err = nil
+	}
+
+	b.onResult(err)
+}
+
+// NewBreakers used to create new breakers registry
+func NewBreakers() *Breakers {
+	return &Breakers{data: make(map[string]*Breaker)}
+}
+
+// Get used to get plugin's breaker, if it's not exist yet, new breaker will be created
+// using given options.  Return nil if plugin doesn't have any breaker and options is nil
+func (b *Breakers) Get(name string, opts *BreakerOptions) *Breaker {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if breaker, exist := b.data[name]; exist {
+		return breaker
+	}
+
+	if opts == nil {
+		return nil
+	}
+
+	breaker := NewBreaker(opts)
+	b.data[name] = breaker
+	return breaker
+}
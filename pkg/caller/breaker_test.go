@@ -0,0 +1,116 @@
+package caller_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/mocks"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/host"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func makeBreakerPlugin(transporter caller.Caller, opts *caller.BreakerOptions) *caller.Plugin {
+	policy := makeRetryPolicy(1)
+	return caller.NewWithRetryPolicy(&host.Registry{}, transporter, policy).WithBreaker(caller.NewBreaker(opts))
+}
+
+func TestBreakerOpenConsecutiveFailures(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Return(nil, errs.ErrProtocolRESTRequest)
+
+	plugin := makeBreakerPlugin(mockCaller, &caller.BreakerOptions{
+		ConsecutiveFailures: 2,
+		CoolDown:            time.Minute,
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := plugin.Exec("test.action", []byte("hello"))
+		assert.True(t, errors.Is(err, errs.ErrProtocolRESTRequest) || errors.Is(err, errs.ErrPluginRetryExhausted))
+	}
+
+	assert.Equal(t, caller.BreakerOpen, plugin.BreakerState())
+
+	_, err := plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginCircuitOpen))
+	mockCaller.AssertNumberOfCalls(t, "ExecContext", 2)
+}
+
+func TestBreakerOpenFailureRate(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("PingContext", mock.Anything).Once().Return("pong", nil)
+	mockCaller.On("PingContext", mock.Anything).Return("", errs.ErrProtocolRESTRequest)
+
+	plugin := makeBreakerPlugin(mockCaller, &caller.BreakerOptions{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		CoolDown:    time.Minute,
+	})
+
+	for i := 0; i < 3; i++ {
+		plugin.Ping()
+		assert.Equal(t, caller.BreakerClosed, plugin.BreakerState())
+	}
+
+	plugin.Ping()
+	assert.Equal(t, caller.BreakerOpen, plugin.BreakerState())
+}
+
+func TestBreakerHalfOpenProbeSuccess(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return(nil, errs.ErrProtocolRESTRequest)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return([]byte("world"), nil)
+	mockCaller.On("PingContext", mock.Anything).Once().Return("pong", nil)
+
+	plugin := makeBreakerPlugin(mockCaller, &caller.BreakerOptions{
+		ConsecutiveFailures: 1,
+		CoolDown:            50 * time.Millisecond,
+	})
+
+	_, err := plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.Equal(t, caller.BreakerOpen, plugin.BreakerState())
+
+	time.Sleep(100 * time.Millisecond)
+	resp, err := plugin.Exec("test.action", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("world"), resp)
+	assert.Equal(t, caller.BreakerClosed, plugin.BreakerState())
+	mockCaller.AssertNumberOfCalls(t, "PingContext", 1)
+}
+
+func TestBreakerHalfOpenProbeFailed(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return(nil, errs.ErrProtocolRESTRequest)
+	mockCaller.On("PingContext", mock.Anything).Once().Return("", errs.ErrProtocolRESTRequest)
+
+	plugin := makeBreakerPlugin(mockCaller, &caller.BreakerOptions{
+		ConsecutiveFailures: 1,
+		CoolDown:            50 * time.Millisecond,
+	})
+
+	_, err := plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginCircuitOpen))
+	assert.Equal(t, caller.BreakerOpen, plugin.BreakerState())
+	mockCaller.AssertNumberOfCalls(t, "ExecContext", 1)
+}
+
+func TestBreakersGet(t *testing.T) {
+	breakers := caller.NewBreakers()
+	assert.Nil(t, breakers.Get("test", nil))
+
+	breaker := breakers.Get("test", &caller.BreakerOptions{ConsecutiveFailures: 1})
+	assert.NotNil(t, breaker)
+	assert.Equal(t, breaker, breakers.Get("test", nil))
+	assert.Equal(t, "closed", breaker.State().String())
+}
@@ -1,8 +1,7 @@
 package caller
 
 import (
-	"log"
-	"time"
+	"context"
 
 	"github.com/quadroops/goplugin/pkg/errs"
 	"github.com/quadroops/goplugin/pkg/host"
@@ -22,21 +21,62 @@ func init() {
 	ignoredErrors[errs.ErrPluginCall] = true
 }
 
-// New used to create plugin's instance
+// New used to create plugin's instance, given retryTimeout (in seconds) will be used
+// as initial interval of default retry policy
 func New(meta *host.Registry, transporter Caller, retryTimeout int) *Plugin {
-	return &Plugin{meta, transporter, retryTimeout}
+	return NewWithRetryPolicy(meta, transporter, DefaultRetryPolicy(retryTimeout))
+}
+
+// NewWithRetryPolicy used to create plugin's instance with custom retry policy
+func NewWithRetryPolicy(meta *host.Registry, transporter Caller, policy *RetryPolicy) *Plugin {
+	if policy == nil {
+		policy = DefaultRetryPolicy(0)
+	}
+
+	return &Plugin{
+		Meta:        meta,
+		transporter: transporter,
+		retryPolicy: policy.withDefaults(),
+	}
+}
+
+// WithBreaker used to attach circuit breaker to current plugin's instance
+func (p *Plugin) WithBreaker(breaker *Breaker) *Plugin {
+	p.breaker = breaker
+	return p
+}
+
+// BreakerState used to get plugin's circuit breaker state, a plugin without
+// circuit breaker will always be closed
+func (p *Plugin) BreakerState() BreakerState {
+	if p.breaker == nil {
+		return BreakerClosed
+	}
+
+	return p.breaker.State()
 }
 
 // Ping used to send ping request to plugin
 func (p *Plugin) Ping() (string, error) {
-	resp, err := p.transporter.Ping()
-	if err != nil {
-		if _, exist := ignoredErrors[err]; !exist {
-			log.Printf("Retrying process error: %v", err)
-			time.Sleep(time.Duration(p.retryTimeout) * time.Second)
-			return p.Ping()
+	return p.PingContext(context.Background())
+}
+
+// PingContext used to send ping request to plugin, the request and also
+// the retry process will be stopped when given context is done
+func (p *Plugin) PingContext(ctx context.Context) (string, error) {
+	var resp string
+	err := p.retryPolicy.retry(ctx, func() error {
+		if err := p.guard(ctx); err != nil {
+			return err
 		}
 
+		var err error
+		resp, err = p.transporter.PingContext(ctx)
+		p.record(err)
+		return err
+	})
+
+	if err != nil {
 		return "", err
 	}
 
@@ -45,16 +85,46 @@ func (p *Plugin) Ping() (string, error) {
 
 // Exec used to send exec request to plugin
 func (p *Plugin) Exec(cmdName string, payload []byte) ([]byte, error) {
-	resp, err := p.transporter.Exec(cmdName, payload)
-	if err != nil {
-		if _, exist := ignoredErrors[err]; !exist {
-			log.Printf("Retrying process error: %v", err)
-			time.Sleep(time.Duration(p.retryTimeout) * time.Second)
-			return p.Exec(cmdName, payload)
+	return p.ExecContext(context.Background(), cmdName, payload)
+}
+
+// ExecContext used to send exec request to plugin, the request and also
+// the retry process will be stopped when given context is done
+func (p *Plugin) ExecContext(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	var resp []byte
+	err := p.retryPolicy.retry(ctx, func() error {
+		if err := p.guard(ctx); err != nil {
+			return err
 		}
 
+		var err error
+		resp, err = p.transporter.ExecContext(ctx, cmdName, payload)
+		p.record(err)
+		return err
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
 	return resp, nil
 }
+
+// guard used to reject a request when plugin's circuit breaker is open, a half-open
+// breaker will use Ping as its probe request
+func (p *Plugin) guard(ctx context.Context) error {
+	if p.breaker == nil {
+		return nil
+	}
+
+	return p.breaker.guard(ctx, func(ctx context.Context) error {
+		_, err := p.transporter.PingContext(ctx)
+		return err
+	})
+}
+
+func (p *Plugin) record(err error) {
+	if p.breaker != nil {
+		p.breaker.record(err)
+	}
+}
@@ -1,8 +1,10 @@
 package caller_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/quadroops/goplugin/pkg/errs"
 
@@ -115,7 +117,7 @@ func TestPingSuccess(t *testing.T) {
 	assert.NoError(t, err)
 
 	mockCaller := new(mocks.Caller)
-	mockCaller.On("Ping").Once().Return("pong", nil)
+	mockCaller.On("PingContext", mock.Anything).Once().Return("pong", nil)
 
 	meta, err := container.GetPluginMeta("name_1")
 	assert.NoError(t, err)
@@ -151,7 +153,7 @@ func TestPingError(t *testing.T) {
 	assert.NoError(t, err)
 
 	mockCaller := new(mocks.Caller)
-	mockCaller.On("Ping").Once().Return("", errs.ErrPluginPing)
+	mockCaller.On("PingContext", mock.Anything).Once().Return("", errs.ErrPluginPing)
 
 	meta, err := container.GetPluginMeta("name_1")
 	assert.NoError(t, err)
@@ -187,7 +189,7 @@ func TestExecSuccess(t *testing.T) {
 	assert.NoError(t, err)
 
 	mockCaller := new(mocks.Caller)
-	mockCaller.On("Exec", "test.action", []byte("hello")).Once().Return([]byte("world"), nil)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return([]byte("world"), nil)
 
 	meta, err := container.GetPluginMeta("name_1")
 	assert.NoError(t, err)
@@ -223,7 +225,7 @@ func TestExecError(t *testing.T) {
 	assert.NoError(t, err)
 
 	mockCaller := new(mocks.Caller)
-	mockCaller.On("Exec", "test.action", []byte("hello")).Once().Return(nil, errs.ErrPluginExec)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return(nil, errs.ErrPluginExec)
 
 	meta, err := container.GetPluginMeta("name_1")
 	assert.NoError(t, err)
@@ -233,3 +235,31 @@ func TestExecError(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, errs.ErrPluginExec))
 }
+
+func TestPingContextCancelledOnRetry(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("PingContext", mock.Anything).Return("", errs.ErrProtocolRESTRequest)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	plugin := caller.New(&host.Registry{}, mockCaller, 3)
+	_, err := plugin.PingContext(ctx)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	mockCaller.AssertNumberOfCalls(t, "PingContext", 1)
+}
+
+func TestExecContextCancelled(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Return(nil, errs.ErrProtocolRESTRequest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	plugin := caller.New(&host.Registry{}, mockCaller, 3)
+	_, err := plugin.ExecContext(ctx, "test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	mockCaller.AssertNumberOfCalls(t, "ExecContext", 1)
+}
@@ -0,0 +1,65 @@
+package caller
+
+import (
+	"context"
+
+	"github.com/quadroops/goplugin/pkg/codec"
+)
+
+// Codec used to get plugin's codec configured on plugin's registry, JSON will be
+// used if the plugin doesn't define any codecs
+func (p *Plugin) Codec() (codec.Codec, error) {
+	return p.CodecContext(context.Background())
+}
+
+// CodecContext used to negotiate plugin's codec with given context.  If the caller
+// implements CodecNegotiator, configured codec must be supported by the plugin, or
+// plugin's preferred codec will be used when nothing configured
+func (p *Plugin) CodecContext(ctx context.Context) (codec.Codec, error) {
+	var configured string
+	if p.Meta != nil {
+		configured = p.Meta.Codec
+	}
+
+	var supported []string
+	if negotiator, ok := p.transporter.(CodecNegotiator); ok {
+		// plugin that cannot be pinged keeps using configured codec
+		codecs, err := negotiator.Codecs(ctx)
+		if err == nil {
+			supported = codecs
+		}
+	}
+
+	return codec.Negotiate(configured, supported)
+}
+
+// ExecInto used to encode in using plugin's codec, send it to the plugin and decode
+// plugin's response into out.  Response will be ignored if out is nil
+func ExecInto(p *Plugin, cmdName string, in, out interface{}) error {
+	return ExecIntoContext(context.Background(), p, cmdName, in, out)
+}
+
+// ExecIntoContext used to exec plugin's command like ExecInto with given context,
+// codec's content type will be sent to the plugin
+func ExecIntoContext(ctx context.Context, p *Plugin, cmdName string, in, out interface{}) error {
+	c, err := p.CodecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := c.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.ExecContext(codec.WithContentType(ctx, c.ContentType()), cmdName, payload)
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return c.Unmarshal(resp, out)
+}
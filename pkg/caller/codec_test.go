@@ -0,0 +1,114 @@
+package caller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/mocks"
+	"github.com/quadroops/goplugin/pkg/codec"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/host"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type execPayload struct {
+	Message string `msgpack:"message" json:"message"`
+}
+
+func TestExecInto(t *testing.T) {
+	in, _ := msgpack.Marshal(execPayload{Message: "hello"})
+	out, _ := msgpack.Marshal(execPayload{Message: "world"})
+
+	withMsgpack := mock.MatchedBy(func(ctx context.Context) bool {
+		return codec.ContentType(ctx) == "application/msgpack"
+	})
+
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", withMsgpack, "test.action", in).Once().Return(out, nil)
+
+	plugin := caller.New(&host.Registry{Codec: "msgpack"}, mockCaller, 3)
+
+	var resp execPayload
+	err := caller.ExecInto(plugin, "test.action", execPayload{Message: "hello"}, &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", resp.Message)
+}
+
+func TestExecIntoDefaultCodec(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte(`{"message":"hello"}`)).Once().Return([]byte(`{"message":"world"}`), nil)
+
+	plugin := caller.New(&host.Registry{}, mockCaller, 3)
+
+	var resp execPayload
+	err := caller.ExecInto(plugin, "test.action", execPayload{Message: "hello"}, &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", resp.Message)
+}
+
+func TestExecIntoUnknownCodec(t *testing.T) {
+	plugin := caller.New(&host.Registry{Codec: "unknown"}, new(mocks.Caller), 3)
+	err := caller.ExecInto(plugin, "test.action", execPayload{}, nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCodecUnknown))
+}
+
+func TestExecIntoInvalidResponse(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", mock.Anything).Once().Return([]byte("invalid"), nil)
+
+	plugin := caller.New(&host.Registry{}, mockCaller, 3)
+
+	var resp execPayload
+	err := caller.ExecInto(plugin, "test.action", execPayload{}, &resp)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrCodecUnmarshal))
+}
+
+// negotiatingCaller used as a caller which knows plugin's supported codecs
+type negotiatingCaller struct {
+	*mocks.Caller
+	codecs []string
+	err    error
+}
+
+func (c *negotiatingCaller) Codecs(_ context.Context) ([]string, error) {
+	return c.codecs, c.err
+}
+
+func TestPluginCodecNegotiated(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configured string
+		codecs     []string
+		err        error
+		expected   string
+		codecErr   error
+	}{
+		{"plugin's preference", "", []string{"msgpack", "json"}, nil, "msgpack", nil},
+		{"configured supported", "json", []string{"msgpack", "json"}, nil, "json", nil},
+		{"configured unsupported", "msgpack", []string{"json"}, nil, "", errs.ErrCodecUnsupported},
+		{"not advertised", "msgpack", nil, nil, "msgpack", nil},
+		{"ping failed", "msgpack", nil, errs.ErrPluginPing, "msgpack", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transporter := &negotiatingCaller{new(mocks.Caller), tc.codecs, tc.err}
+			plugin := caller.New(&host.Registry{Codec: tc.configured}, transporter, 3)
+
+			c, err := plugin.Codec()
+			if tc.codecErr != nil {
+				assert.True(t, errors.Is(err, tc.codecErr))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, c.Name())
+		})
+	}
+}
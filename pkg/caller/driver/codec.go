@@ -0,0 +1,43 @@
+package driver
+
+import (
+	"context"
+	"sync"
+)
+
+// codecs used to keep plugin's supported codecs advertised on its latest ping response
+type codecs struct {
+	mutex sync.Mutex
+	known bool
+	names []string
+}
+
+func (c *codecs) set(names []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.known = true
+	c.names = names
+}
+
+func (c *codecs) get() ([]string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.names, c.known
+}
+
+// negotiate used to get plugin's supported codecs, the plugin will be pinged
+// if its codecs not known yet.  Empty codecs means plugin doesn't advertise any
+func (c *codecs) negotiate(ctx context.Context, ping func(ctx context.Context) (string, error)) ([]string, error) {
+	if names, known := c.get(); known {
+		return names, nil
+	}
+
+	if _, err := ping(ctx); err != nil {
+		return nil, err
+	}
+
+	names, _ := c.get()
+	return names, nil
+}
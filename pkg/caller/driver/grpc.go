@@ -3,10 +3,16 @@ package driver
 import (
 	"context"
 	"fmt"
+	"io"
+	"sync"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
 
+	"github.com/quadroops/goplugin/pkg/callback"
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/codec"
 	"github.com/quadroops/goplugin/pkg/errs"
 	pbPlugin "github.com/quadroops/goplugin/proto/plugin"
 )
@@ -15,23 +21,126 @@ import (
 type GrpcClientConnector func(addr string, port int) (pbPlugin.PluginClient, error)
 
 // DefaultGrpcClientConnector used as default client object to create grpc
-// connection
+// connection, the connection will be shared using DefaultGrpcConnManager
 func DefaultGrpcClientConnector(addr string, port int) (pbPlugin.PluginClient, error) {
-	endpoint := fmt.Sprintf("%s:%d", addr, port)
-	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
-	if err != nil {
-		return nil, err
-	}
-
-	client := pbPlugin.NewPluginClient(conn)
-	return client, nil
+	return DefaultGrpcConnManager.Connect(addr, port)
 }
 
-// GrpcOptions used to save grpc options
+// GrpcOptions used to save grpc options.  Conns used as connection manager
+// when Connector is not defined, by default will use DefaultGrpcConnManager.
+// TLS will be ignored if Connector defined.  If Socket defined, the caller will
+// connect to plugin's unix domain socket instead of Addr and Port.  Options used by
+// running callers should be changed using SetAddress, SetSocket and SetTLS
 type GrpcOptions struct {
 	Addr      string
 	Port      int
+	Socket    string
 	Connector GrpcClientConnector
+	Conns     *GrpcConnManager
+	TLS       *TLSOptions
+
+	mutex        sync.Mutex
+	tlsConnector GrpcClientConnector
+	tlsOpts      *TLSOptions
+	codecs       codecs
+}
+
+// SetAddress used to change plugin's address and port
+func (o *GrpcOptions) SetAddress(addr string, port int) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.Addr = addr
+	o.Port = port
+}
+
+// SetSocket used to change plugin's unix domain socket
+func (o *GrpcOptions) SetSocket(socket string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.Socket = socket
+}
+
+// SetTLS used to change connection's tls options, next calls will use
+// a connection dialed with the new options
+func (o *GrpcOptions) SetTLS(tls *TLSOptions) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.TLS = tls
+}
+
+// GetTLS used to get current connection's tls options
+func (o *GrpcOptions) GetTLS() *TLSOptions {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.TLS
+}
+
+// target used to get address and port given to the connector
+func (o *GrpcOptions) target() (string, int) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.endpoint()
+}
+
+// endpoint used to get address and port, it must be called while holding the mutex
+func (o *GrpcOptions) endpoint() (string, int) {
+	if o.Socket != "" {
+		return fmt.Sprintf("%s%s", UnixSchema, o.Socket), 0
+	}
+
+	return o.Addr, o.Port
+}
+
+// conns used to get options's connection manager
+func (o *GrpcOptions) conns() *GrpcConnManager {
+	if o.Conns != nil {
+		return o.Conns
+	}
+
+	return DefaultGrpcConnManager
+}
+
+// client used to get plugin's client for current endpoint, if Connector not defined
+// the client will use managed connection dialed with current tls options
+func (o *GrpcOptions) client() (pbPlugin.PluginClient, error) {
+	o.mutex.Lock()
+	addr, port := o.endpoint()
+	connector := o.Connector
+	if connector == nil {
+		connector = o.conns().Connect
+		if o.TLS != nil {
+			// the same connector should be reused to keep sharing its connection
+			if o.tlsConnector == nil || o.tlsOpts != o.TLS {
+				o.tlsConnector = o.conns().ConnectorWithTLS(o.TLS)
+				o.tlsOpts = o.TLS
+			}
+
+			connector = o.tlsConnector
+		}
+	}
+	o.mutex.Unlock()
+
+	return connector(addr, port)
+}
+
+// Close used to close managed client connection for current endpoint, it will
+// do nothing if client connections managed by custom Connector
+func (o *GrpcOptions) Close() error {
+	o.mutex.Lock()
+	addr, port := o.endpoint()
+	connector := o.Connector
+	o.mutex.Unlock()
+
+	if connector != nil {
+		return nil
+	}
+
+	return o.conns().Close(addr, port)
 }
 
 // GrpcObj used as main grpc struct object
@@ -42,45 +151,176 @@ type GrpcObj struct {
 // NewGRPC return new instance that implement Caller specifically
 // for grpc's protocol
 func NewGRPC(opt *GrpcOptions) *GrpcObj {
-	// if caller not giving connector config
-	// we're need to use default connector
-	if opt.Connector == nil {
-		opt.Connector = DefaultGrpcClientConnector
-	}
-
 	return &GrpcObj{opt}
 }
 
 // Ping implement caller.Caller ping method
 func (g *GrpcObj) Ping() (string, error) {
-	client, err := g.opt.Connector(g.opt.Addr, g.opt.Port)
+	return g.PingContext(context.Background())
+}
+
+// PingContext implement caller.Caller ping method with given context
+func (g *GrpcObj) PingContext(ctx context.Context) (string, error) {
+	client, err := g.opt.client()
 	if err != nil {
 		return "", fmt.Errorf("%w: %q", errs.ErrProtocolGRPCConnection, err)
 	}
 
-	resp, err := client.Ping(context.Background(), &emptypb.Empty{})
+	var header metadata.MD
+	resp, err := client.Ping(ctx, &emptypb.Empty{}, grpc.Header(&header))
 	if err != nil {
 		return "", fmt.Errorf("%w: %q", errs.ErrPluginPing, err)
 	}
 
+	g.opt.codecs.set(codec.Parse(header.Get(codec.MetadataCodecs)...))
 	return resp.GetData().GetResponse(), nil
 }
 
+// Codecs implement caller.CodecNegotiator using plugin's ping response header
+func (g *GrpcObj) Codecs(ctx context.Context) ([]string, error) {
+	return g.opt.codecs.negotiate(ctx, g.PingContext)
+}
+
 // Exec implement caller.Caller exec method
 func (g *GrpcObj) Exec(cmdName string, payload []byte) ([]byte, error) {
-	client, err := g.opt.Connector(g.opt.Addr, g.opt.Port)
+	return g.ExecContext(context.Background(), cmdName, payload)
+}
+
+// ExecContext implement caller.Caller exec method with given context
+func (g *GrpcObj) ExecContext(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	client, err := g.opt.client()
 	if err != nil {
 		return nil, fmt.Errorf("%w: %q", errs.ErrProtocolGRPCConnection, err)
 	}
 
-	resp, err := client.Exec(context.Background(), &pbPlugin.ExecRequest{
+	resp, err := client.Exec(outgoingContext(ctx), &pbPlugin.ExecRequest{
 		Command: cmdName,
 		Payload: payload,
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+		return nil, grpcError(err)
 	}
 
 	return resp.GetData().GetResponse(), nil
 }
+
+// ExecStream implement caller.StreamCaller using grpc's server streaming
+func (g *GrpcObj) ExecStream(ctx context.Context, cmdName string, payload []byte) (caller.Stream, error) {
+	client, err := g.opt.client()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrProtocolGRPCConnection, err)
+	}
+
+	ctx, cancel := context.WithCancel(outgoingContext(ctx))
+	stream, err := client.ExecStream(ctx, &pbPlugin.ExecRequest{
+		Command: cmdName,
+		Payload: payload,
+	})
+
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return &grpcStream{stream, cancel}, nil
+}
+
+type grpcStream struct {
+	stream pbPlugin.Plugin_ExecStreamClient
+	cancel context.CancelFunc
+}
+
+func (s *grpcStream) Recv() ([]byte, error) {
+	chunk, err := s.stream.Recv()
+	if err == io.EOF {
+		s.cancel()
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		s.cancel()
+		return nil, grpcError(err)
+	}
+
+	return chunk.GetData(), nil
+}
+
+func (s *grpcStream) Close() error {
+	s.cancel()
+	return nil
+}
+
+// Session implement caller.SessionCaller using grpc's bidirectional streaming, the
+// first message sent to the plugin will only contain the command
+func (g *GrpcObj) Session(ctx context.Context, cmdName string) (caller.Session, error) {
+	client, err := g.opt.client()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrProtocolGRPCConnection, err)
+	}
+
+	ctx, cancel := context.WithCancel(outgoingContext(ctx))
+	stream, err := client.Session(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	err = stream.Send(&pbPlugin.SessionMessage{Command: cmdName})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return &grpcSession{stream, cancel}, nil
+}
+
+type grpcSession struct {
+	stream pbPlugin.Plugin_SessionClient
+	cancel context.CancelFunc
+}
+
+func (s *grpcSession) Send(data []byte) error {
+	err := s.stream.Send(&pbPlugin.SessionMessage{Data: data})
+	if err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return nil
+}
+
+func (s *grpcSession) Recv() ([]byte, error) {
+	msg, err := s.stream.Recv()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return msg.GetData(), nil
+}
+
+func (s *grpcSession) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+func (s *grpcSession) Close() error {
+	s.cancel()
+	return nil
+}
+
+// outgoingContext used to send call chain and payload's content type from ctx as
+// grpc's metadata
+func outgoingContext(ctx context.Context) context.Context {
+	if chain := callback.CallChain(ctx); len(chain) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, callback.MetadataCallChain, callback.FormatCallChain(chain))
+	}
+
+	if contentType := codec.ContentType(ctx); contentType != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, codec.MetadataContentType, contentType)
+	}
+
+	return ctx
+}
@@ -0,0 +1,189 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+
+	pbPlugin "github.com/quadroops/goplugin/proto/plugin"
+)
+
+var (
+	// DefaultGrpcConnManager used by DefaultGrpcClientConnector to share
+	// client connections between grpc callers
+	DefaultGrpcConnManager = NewGrpcConnManager(grpc.WithInsecure())
+)
+
+// GrpcConnManager used to keep a single grpc client connection per plugin's endpoint and
+// dial options, a connection will be reused by all callers until it's closed
+type GrpcConnManager struct {
+	mutex    sync.Mutex
+	conns    map[connKey]*grpc.ClientConn
+	dialOpts []grpc.DialOption
+}
+
+// connKey used to separate connections dialed to the same endpoint using different
+// dial options, such as insecure and TLS connections
+type connKey struct {
+	endpoint string
+	identity string
+}
+
+// dialIdentity used to identify given dial options, options built by grpc are
+// pointers so the same options will have the same identity
+func dialIdentity(opts []grpc.DialOption) string {
+	ids := make([]string, len(opts))
+	for i, opt := range opts {
+		ids[i] = fmt.Sprintf("%T:%p", opt, opt)
+	}
+
+	return strings.Join(ids, ",")
+}
+
+// NewGrpcConnManager used to create new connection manager, given dial options
+// will be used when creating new connection
+func NewGrpcConnManager(opts ...grpc.DialOption) *GrpcConnManager {
+	return &GrpcConnManager{
+		conns:    make(map[connKey]*grpc.ClientConn),
+		dialOpts: opts,
+	}
+}
+
+// Connect implement GrpcClientConnector using managed connection
+func (m *GrpcConnManager) Connect(addr string, port int) (pbPlugin.PluginClient, error) {
+	conn, err := m.Conn(addr, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return pbPlugin.NewPluginClient(conn), nil
+}
+
+// ConnectorWithTLS used to create GrpcClientConnector using managed connection
+// secured by given TLS options, tls configurations will be built only once
+func (m *GrpcConnManager) ConnectorWithTLS(opts *TLSOptions) GrpcClientConnector {
+	var once sync.Once
+	var creds grpc.DialOption
+	var errConf error
+
+	return func(addr string, port int) (pbPlugin.PluginClient, error) {
+		once.Do(func() {
+			tlsConf, err := opts.Config()
+			if err != nil {
+				errConf = err
+				return
+			}
+
+			creds = grpc.WithTransportCredentials(credentials.NewTLS(tlsConf))
+		})
+
+		if errConf != nil {
+			return nil, errConf
+		}
+
+		conn, err := m.Conn(addr, port, creds)
+		if err != nil {
+			return nil, err
+		}
+
+		return pbPlugin.NewPluginClient(conn), nil
+	}
+}
+
+// UnixSchema used as address's prefix for plugins listening on unix domain socket
+const UnixSchema = "unix://"
+
+func grpcEndpoint(addr string, port int) string {
+	if strings.HasPrefix(addr, UnixSchema) {
+		return addr
+	}
+
+	return fmt.Sprintf("%s:%d", addr, port)
+}
+
+func unixDialer(ctx context.Context, endpoint string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", strings.TrimPrefix(endpoint, UnixSchema))
+}
+
+// Conn used to get existing client connection for given endpoint, new connection
+// will be dialed if it's not exist yet or it has been shutdown.  If dial options
+// given, they will be used instead of manager's dial options, and the connection
+// will only be shared with callers using the same options.  An address prefixed
+// by unix:// will be dialed as unix domain socket and the port will be ignored
+func (m *GrpcConnManager) Conn(addr string, port int, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	endpoint := grpcEndpoint(addr, port)
+	if len(opts) < 1 {
+		opts = m.dialOpts
+	}
+
+	key := connKey{endpoint: endpoint, identity: dialIdentity(opts)}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	conn, exist := m.conns[key]
+	if exist && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+
+	if strings.HasPrefix(endpoint, UnixSchema) {
+		opts = append(opts[:len(opts):len(opts)], grpc.WithContextDialer(unixDialer))
+	}
+
+	conn, err := grpc.Dial(endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.conns[key] = conn
+	return conn, nil
+}
+
+// Close used to close all client connections for given endpoint, next call to the
+// endpoint will dial a new connection
+func (m *GrpcConnManager) Close(addr string, port int) error {
+	endpoint := grpcEndpoint(addr, port)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var errGroups error
+	for key, conn := range m.conns {
+		if key.endpoint != endpoint {
+			continue
+		}
+
+		if err := conn.Close(); err != nil {
+			errGroups = multierror.Append(errGroups, err)
+		}
+
+		delete(m.conns, key)
+	}
+
+	return errGroups
+}
+
+// CloseAll used to close all managed client connections
+func (m *GrpcConnManager) CloseAll() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var errGroups error
+	for key, conn := range m.conns {
+		if err := conn.Close(); err != nil {
+			errGroups = multierror.Append(errGroups, err)
+		}
+
+		delete(m.conns, key)
+	}
+
+	return errGroups
+}
@@ -0,0 +1,113 @@
+package driver_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	pbPlugin "github.com/quadroops/goplugin/proto/plugin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGrpcConnReused(t *testing.T) {
+	manager := driver.NewGrpcConnManager(grpc.WithInsecure())
+	defer manager.CloseAll()
+
+	conn1, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+
+	conn2, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+	assert.Same(t, conn1, conn2)
+
+	conn3, err := manager.Conn("localhost", 8081)
+	assert.NoError(t, err)
+	assert.NotSame(t, conn1, conn3)
+}
+
+func TestGrpcConnClosed(t *testing.T) {
+	manager := driver.NewGrpcConnManager(grpc.WithInsecure())
+	defer manager.CloseAll()
+
+	conn1, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+
+	err = manager.Close("localhost", 8080)
+	assert.NoError(t, err)
+
+	conn2, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+	assert.NotSame(t, conn1, conn2)
+}
+
+func TestGrpcOptionsClose(t *testing.T) {
+	manager := driver.NewGrpcConnManager(grpc.WithInsecure())
+	defer manager.CloseAll()
+
+	opt := &driver.GrpcOptions{
+		Addr:  "localhost",
+		Port:  8080,
+		Conns: manager,
+	}
+
+	rpc := driver.NewGRPC(opt)
+	assert.NotNil(t, rpc)
+
+	conn1, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+	assert.NoError(t, opt.Close())
+
+	conn2, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+	assert.NotSame(t, conn1, conn2)
+}
+
+func TestGrpcOptionsCloseCustomConnector(t *testing.T) {
+	manager := driver.NewGrpcConnManager(grpc.WithInsecure())
+	defer manager.CloseAll()
+
+	opt := &driver.GrpcOptions{
+		Addr:  "localhost",
+		Port:  8080,
+		Conns: manager,
+		Connector: func(addr string, port int) (pbPlugin.PluginClient, error) {
+			return nil, nil
+		},
+	}
+
+	// the managed connection may be shared by another plugin
+	conn1, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+	assert.NoError(t, opt.Close())
+
+	conn2, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+	assert.Same(t, conn1, conn2)
+}
+
+func TestGrpcConnSeparatedByDialOptions(t *testing.T) {
+	manager := driver.NewGrpcConnManager(grpc.WithInsecure())
+	defer manager.CloseAll()
+
+	insecure, err := manager.Conn("localhost", 8080)
+	assert.NoError(t, err)
+
+	// a TLS caller must not reuse an insecure connection
+	creds := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+	secure1, err := manager.Conn("localhost", 8080, creds)
+	assert.NoError(t, err)
+	assert.NotSame(t, insecure, secure1)
+
+	secure2, err := manager.Conn("localhost", 8080, creds)
+	assert.NoError(t, err)
+	assert.Same(t, secure1, secure2)
+
+	// closing an endpoint closes all of its connections
+	assert.NoError(t, manager.Close("localhost", 8080))
+
+	conn, err := manager.Conn("localhost", 8080, creds)
+	assert.NoError(t, err)
+	assert.NotSame(t, secure1, conn)
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// ErrorDomain used as ErrorInfo's domain of plugin's errors
+const ErrorDomain = "goplugin"
+
+// NewGRPCError used by grpc plugins to return errs.PluginError to the host.  Code and
+// details will be sent as ErrorInfo's reason and metadata, and a retryable error will
+// also contain RetryInfo
+func NewGRPCError(pluginErr *errs.PluginError) error {
+	st := status.New(codes.Unknown, pluginErr.Message)
+	details := []proto.Message{&errdetails.ErrorInfo{
+		Reason:   pluginErr.Code,
+		Domain:   ErrorDomain,
+		Metadata: pluginErr.Details,
+	}}
+
+	if pluginErr.Retryable {
+		details = append(details, &errdetails.RetryInfo{})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// grpcError used to convert grpc's status error into errs.PluginError if it contains
+// ErrorInfo, otherwise it will be wrapped as errs.ErrPluginExec
+func grpcError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	var pluginErr *errs.PluginError
+	retryable := false
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			pluginErr = &errs.PluginError{
+				Code:    d.GetReason(),
+				Message: st.Message(),
+				Details: d.GetMetadata(),
+			}
+		case *errdetails.RetryInfo:
+			retryable = true
+		}
+	}
+
+	if pluginErr == nil {
+		return fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	pluginErr.Retryable = retryable
+	return pluginErr
+}
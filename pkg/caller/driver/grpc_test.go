@@ -8,10 +8,14 @@ import (
 	"github.com/golang/protobuf/ptypes/empty"
 
 	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/codec"
 	"github.com/quadroops/goplugin/pkg/errs"
 	pbPlugin "github.com/quadroops/goplugin/proto/plugin"
 	"github.com/quadroops/goplugin/proto/plugin/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func makeGrpcOptions(addr string, port int, connector driver.GrpcClientConnector) *driver.GrpcOptions {
@@ -24,7 +28,7 @@ func makeGrpcOptions(addr string, port int, connector driver.GrpcClientConnector
 
 func TestPingSuccess(t *testing.T) {
 	client := new(mocks.PluginClient)
-	client.On("Ping", context.Background(), &empty.Empty{}).Once().Return(&pbPlugin.PingResponse{
+	client.On("Ping", context.Background(), &empty.Empty{}, mock.Anything).Once().Return(&pbPlugin.PingResponse{
 		Status: "success",
 		Data: &pbPlugin.Data{
 			Response: "pong",
@@ -53,7 +57,7 @@ func TestPingErrorClient(t *testing.T) {
 
 func TestPingErrorResponse(t *testing.T) {
 	client := new(mocks.PluginClient)
-	client.On("Ping", context.Background(), &empty.Empty{}).Once().Return(&pbPlugin.PingResponse{}, errors.New("err response"))
+	client.On("Ping", context.Background(), &empty.Empty{}, mock.Anything).Once().Return(&pbPlugin.PingResponse{}, errors.New("err response"))
 
 	rpc := driver.NewGRPC(makeGrpcOptions("localhost", 8080, func(addr string, port int) (pbPlugin.PluginClient, error) {
 		return client, nil
@@ -113,3 +117,50 @@ func TestGRPCErrorResponse(t *testing.T) {
 	assert.True(t, errors.Is(err, errs.ErrPluginExec))
 	assert.Empty(t, resp)
 }
+
+func TestGRPCExecPluginError(t *testing.T) {
+	expected := &errs.PluginError{
+		Code:      "NOT_FOUND",
+		Message:   "user not found",
+		Details:   map[string]string{"id": "1"},
+		Retryable: true,
+	}
+
+	client := new(mocks.PluginClient)
+	client.On("Exec", context.Background(), &pbPlugin.ExecRequest{
+		Command: "test.command",
+		Payload: []byte("hello"),
+	}).Once().Return(&pbPlugin.ExecResponse{}, driver.NewGRPCError(expected))
+
+	rpc := driver.NewGRPC(makeGrpcOptions("localhost", 8080, func(addr string, port int) (pbPlugin.PluginClient, error) {
+		return client, nil
+	}))
+
+	_, err := rpc.Exec("test.command", []byte("hello"))
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+
+	var pluginErr *errs.PluginError
+	assert.True(t, errors.As(err, &pluginErr))
+	assert.Equal(t, expected, pluginErr)
+}
+
+func TestGRPCCodecs(t *testing.T) {
+	client := new(mocks.PluginClient)
+	client.On("Ping", mock.Anything, &empty.Empty{}, mock.Anything).Once().Run(func(args mock.Arguments) {
+		header := args.Get(2).(grpc.HeaderCallOption)
+		*header.HeaderAddr = metadata.Pairs(codec.MetadataCodecs, "msgpack,json")
+	}).Return(&pbPlugin.PingResponse{}, nil)
+
+	grpcCaller := driver.NewGRPC(makeGrpcOptions("localhost", 8080, func(addr string, port int) (pbPlugin.PluginClient, error) {
+		return client, nil
+	}))
+
+	// codecs should be negotiated once
+	for i := 0; i < 2; i++ {
+		codecs, err := grpcCaller.Codecs(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"msgpack", "json"}, codecs)
+	}
+
+	client.AssertExpectations(t)
+}
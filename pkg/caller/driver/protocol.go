@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// ProtocolREST used as rest protocol's name
+	ProtocolREST = "rest"
+
+	// ProtocolGRPC used as grpc protocol's name
+	ProtocolGRPC = "grpc"
+
+	// ProtocolStdio used as stdio protocol's name
+	ProtocolStdio = "stdio"
+)
+
+func init() {
+	caller.RegisterProtocol(ProtocolREST, RESTFactory)
+	caller.RegisterProtocol(ProtocolGRPC, GRPCFactory)
+	caller.RegisterProtocol(ProtocolStdio, StdioFactory)
+}
+
+func invalidOptions(protocol string, opts interface{}) error {
+	return fmt.Errorf("%w: %s protocol doesn't support %T", errs.ErrProtocolOptions, protocol, opts)
+}
+
+// RESTFactory implement caller.ProtocolFactory for rest protocol, given options
+// must be *RESTOptions or nil
+func RESTFactory(opts interface{}) (caller.Caller, error) {
+	switch o := opts.(type) {
+	case nil:
+		return NewREST(nil), nil
+	case *RESTOptions:
+		return NewREST(o), nil
+	}
+
+	return nil, invalidOptions(ProtocolREST, opts)
+}
+
+// GRPCFactory implement caller.ProtocolFactory for grpc protocol, given options
+// must be *GrpcOptions
+func GRPCFactory(opts interface{}) (caller.Caller, error) {
+	if o, ok := opts.(*GrpcOptions); ok && o != nil {
+		return NewGRPC(o), nil
+	}
+
+	return nil, invalidOptions(ProtocolGRPC, opts)
+}
+
+// StdioFactory implement caller.ProtocolFactory for stdio protocol, given options
+// must be *StdioOptions or nil
+func StdioFactory(opts interface{}) (caller.Caller, error) {
+	switch o := opts.(type) {
+	case nil:
+		return NewStdio(nil), nil
+	case *StdioOptions:
+		return NewStdio(o), nil
+	}
+
+	return nil, invalidOptions(ProtocolStdio, opts)
+}
+
+// GetPort implement caller.PortOptions
+func (o *RESTOptions) GetPort() int {
+	if o == nil {
+		return 0
+	}
+
+	_, port, _, _ := o.settings()
+	return port
+}
+
+// GetAddress implement caller.AddressOptions, address's schema will be removed
+func (o *RESTOptions) GetAddress() string {
+	if o == nil {
+		return ""
+	}
+
+	addr, port, socket, _ := o.settings()
+	if socket != "" {
+		return fmt.Sprintf("%s%s", UnixSchema, socket)
+	}
+
+	if i := strings.Index(addr, "://"); i >= 0 {
+		addr = addr[i+3:]
+	}
+
+	return net.JoinHostPort(addr, strconv.Itoa(port))
+}
+
+// GetAddress implement caller.AddressOptions
+func (o *GrpcOptions) GetAddress() string {
+	if o == nil {
+		return ""
+	}
+
+	addr, port := o.target()
+	if strings.HasPrefix(addr, UnixSchema) {
+		return addr
+	}
+
+	return net.JoinHostPort(addr, strconv.Itoa(port))
+}
+
+// GetPort implement caller.PortOptions
+func (o *GrpcOptions) GetPort() int {
+	if o == nil {
+		return 0
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.Port
+}
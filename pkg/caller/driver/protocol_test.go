@@ -0,0 +1,66 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinProtocolsRegistered(t *testing.T) {
+	assert.Equal(t, []string{"grpc", "rest", "stdio"}, filterBuiltin(caller.Protocols()))
+
+	c, err := caller.NewProtocol(driver.ProtocolREST, &driver.RESTOptions{Addr: "http://localhost", Port: 8080})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	c, err = caller.NewProtocol(driver.ProtocolGRPC, &driver.GrpcOptions{Addr: "localhost", Port: 8081})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	c, err = caller.NewProtocol(driver.ProtocolStdio, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestBuiltinProtocolsInvalidOptions(t *testing.T) {
+	_, err := caller.NewProtocol(driver.ProtocolREST, &driver.GrpcOptions{})
+	assert.True(t, errors.Is(err, errs.ErrProtocolOptions))
+
+	_, err = caller.NewProtocol(driver.ProtocolGRPC, nil)
+	assert.True(t, errors.Is(err, errs.ErrProtocolOptions))
+
+	_, err = caller.NewProtocol(driver.ProtocolStdio, &driver.RESTOptions{})
+	assert.True(t, errors.Is(err, errs.ErrProtocolOptions))
+}
+
+func TestBuiltinProtocolsPort(t *testing.T) {
+	assert.Equal(t, 8080, caller.ProtocolPort(&driver.RESTOptions{Port: 8080}))
+	assert.Equal(t, 8081, caller.ProtocolPort(&driver.GrpcOptions{Port: 8081}))
+	assert.Equal(t, 0, caller.ProtocolPort(&driver.StdioOptions{}))
+
+	var opts *driver.RESTOptions
+	assert.Equal(t, 0, caller.ProtocolPort(opts))
+}
+
+func filterBuiltin(names []string) []string {
+	var builtin []string
+	for _, name := range names {
+		if name == driver.ProtocolREST || name == driver.ProtocolGRPC || name == driver.ProtocolStdio {
+			builtin = append(builtin, name)
+		}
+	}
+
+	return builtin
+}
+
+func TestBuiltinProtocolsAddress(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:8080", caller.ProtocolAddress(&driver.RESTOptions{Addr: "https://10.0.0.1", Port: 8080}))
+	assert.Equal(t, "unix:///tmp/plugin.sock", caller.ProtocolAddress(&driver.RESTOptions{Socket: "/tmp/plugin.sock"}))
+	assert.Equal(t, "localhost:8081", caller.ProtocolAddress(&driver.GrpcOptions{Addr: "localhost", Port: 8081}))
+	assert.Equal(t, "unix:///tmp/plugin.sock", caller.ProtocolAddress(&driver.GrpcOptions{Socket: "/tmp/plugin.sock"}))
+	assert.Equal(t, "", caller.ProtocolAddress(&driver.StdioOptions{}))
+}
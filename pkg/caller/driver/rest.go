@@ -2,15 +2,21 @@ package driver
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/quadroops/goplugin/pkg/callback"
 	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/codec"
 	"github.com/quadroops/goplugin/pkg/errs"
 )
 
@@ -21,18 +27,169 @@ const (
 	// PathExec {self explained}
 	PathExec = "/exec"
 
+	// PathExecStream used to send exec request with streaming response
+	PathExecStream = "/exec/stream"
+
 	// Timeout used to waiting client response and cancel the request after limit timeout reached
 	Timeout = 5
 
 	// DefaultSchema used if given address doesn't give any http or https schemes
 	DefaultSchema = "http://"
+
+	// DefaultSecureSchema used instead of DefaultSchema when TLS options defined
+	DefaultSecureSchema = "https://"
+
+	// UnixHost used as request's host when calling plugin through unix domain socket
+	UnixHost = "unix"
 )
 
-// RESTOptions used as main option data
+// RESTOptions used as main option data.  Transport used to configure http transport,
+// if it's not defined, a default transport will be created.  The http client will be
+// created once and reused by all REST callers using the same options.  When TLS defined,
+// the client will use its own copy of transport configured with given TLS options.
+// If Socket defined, the client will send http requests over plugin's unix domain
+// socket instead of Addr and Port, using its own copy of transport too.  Encoding used
+// to choose exec payload's encoding, if it's not defined, the encoding will be negotiated
+// using plugin's ping response.  Options used by running callers should be changed using
+// SetAddress, SetSocket and SetTLS, so the client will be recreated
 type RESTOptions struct {
-	Addr    string
-	Port    int
-	Timeout int
+	Addr      string
+	Port      int
+	Socket    string
+	Timeout   int
+	Encoding  string
+	Transport *RESTTransport
+	TLS       *TLSOptions
+
+	mutex      sync.Mutex
+	client     *http.Client
+	negotiated string
+	codecs     codecs
+}
+
+// Client used to get http client for current options, it will be created once
+func (o *RESTOptions) Client() (*http.Client, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.client != nil {
+		return o.client, nil
+	}
+
+	if o.Transport == nil {
+		o.Transport = &RESTTransport{}
+	}
+
+	rt := o.Transport.Get()
+	if o.TLS != nil {
+		tlsConf, err := o.TLS.Config()
+		if err != nil {
+			return nil, err
+		}
+
+		// custom round tripper should manage their own tls configurations
+		if transport, ok := rt.(*http.Transport); ok {
+			transport = transport.Clone()
+			transport.TLSClientConfig = tlsConf
+			rt = transport
+		}
+	}
+
+	if o.Socket != "" {
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("%w: unix socket requires *http.Transport", errs.ErrProtocolRESTRequest)
+		}
+
+		socket := o.Socket
+		transport = transport.Clone()
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+
+		rt = transport
+	}
+
+	o.client = &http.Client{
+		Timeout:   time.Duration(o.Timeout) * time.Second,
+		Transport: rt,
+	}
+
+	return o.client, nil
+}
+
+// SetAddress used to change plugin's address and port
+func (o *RESTOptions) SetAddress(addr string, port int) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.Addr = addr
+	o.Port = port
+}
+
+// SetSocket used to change plugin's unix domain socket, current client will
+// be replaced if the socket changed
+func (o *RESTOptions) SetSocket(socket string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.Socket == socket {
+		return
+	}
+
+	o.Socket = socket
+	o.reset()
+}
+
+// SetTLS used to change client's tls options, current client will be replaced
+// if the options changed
+func (o *RESTOptions) SetTLS(tls *TLSOptions) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.TLS == tls {
+		return
+	}
+
+	o.TLS = tls
+	o.reset()
+}
+
+// GetTLS used to get current client's tls options
+func (o *RESTOptions) GetTLS() *TLSOptions {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.TLS
+}
+
+// reset used to drop current client, it must be called while holding the mutex
+func (o *RESTOptions) reset() {
+	if o.client != nil {
+		o.client.CloseIdleConnections()
+		o.client = nil
+	}
+}
+
+// settings used to get a consistent copy of plugin's endpoint and tls options
+func (o *RESTOptions) settings() (addr string, port int, socket string, tls *TLSOptions) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.Addr, o.Port, o.Socket, o.TLS
+}
+
+// Close used to close all idle connections owned by current options's client
+func (o *RESTOptions) Close() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.client != nil {
+		o.client.CloseIdleConnections()
+	}
+
+	return nil
 }
 
 // JSONData used as main response
@@ -46,6 +203,17 @@ type JSONResponse struct {
 	Data   JSONData `json:"data"`
 }
 
+// JSONErrorResponse following JSEND standard as plugin's error response.  Data used
+// as error's details and Retryable used to tell the host that the request can be retried.
+// JSEND allows any value as fail's data, non-string values will be kept as json
+type JSONErrorResponse struct {
+	Status    string      `json:"status"`
+	Message   string      `json:"message"`
+	Code      interface{} `json:"code,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Retryable bool        `json:"retryable,omitempty"`
+}
+
 // JSONExecPayload used as main payload when sending exec request
 type JSONExecPayload struct {
 	Cmd     string `json:"command"`
@@ -75,30 +243,64 @@ func NewREST(o *RESTOptions) caller.Caller {
 	return &rest{opt}
 }
 
-func (r *rest) request(method, endpoint string, payload *bytes.Buffer) (*http.Response, error) {
-	timeout := time.Duration(r.option.Timeout) * time.Second
-	client := http.Client{
-		Timeout: timeout,
+// endpoint used to build plugin's endpoint for given path
+func (o *RESTOptions) endpoint(path string) string {
+	addr, port, socket, _ := o.settings()
+	if socket != "" {
+		return fmt.Sprintf("%s%s", UnixHost, path)
 	}
 
+	return fmt.Sprintf("%s:%d%s", addr, port, path)
+}
+
+func (r *rest) newRequest(ctx context.Context, method, endpoint string, payload *bytes.Buffer) (*http.Request, error) {
 	var err error
+	// an address without any schemes such as localhost:8080 or 127.0.0.1:8080
+	// should be prefixed with default schema before parsed
+	if !strings.Contains(endpoint, "://") {
+		schema := DefaultSchema
+		if r.option.GetTLS() != nil {
+			schema = DefaultSecureSchema
+		}
+
+		endpoint = fmt.Sprintf("%s%s", schema, endpoint)
+	}
+
 	u, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %q", errs.ErrProtocolRESTRequest, err)
 	}
 
-	if u.Scheme == "" || u.Scheme == "localhost" {
-		endpoint = fmt.Sprintf("%s%s", DefaultSchema, endpoint)
-	}
-
 	var req *http.Request
 	if payload != nil {
-		req, err = http.NewRequest(method, endpoint, payload)
+		req, err = http.NewRequestWithContext(ctx, method, u.String(), payload)
 	} else {
-		req, err = http.NewRequest(method, endpoint, nil)
+		req, err = http.NewRequestWithContext(ctx, method, u.String(), nil)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if chain := callback.CallChain(ctx); len(chain) > 0 {
+		req.Header.Set(callback.HeaderCallChain, callback.FormatCallChain(chain))
+	}
+
+	if contentType := codec.ContentType(ctx); contentType != "" {
+		req.Header.Set(codec.HeaderContentType, contentType)
+	}
+
+	return req, nil
+}
+
+func (r *rest) request(ctx context.Context, method, endpoint string, payload *bytes.Buffer) (*http.Response, error) {
+	req, err := r.newRequest(ctx, method, endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := r.option.Client()
 	if err != nil {
 		return nil, err
 	}
@@ -106,9 +308,18 @@ func (r *rest) request(method, endpoint string, payload *bytes.Buffer) (*http.Re
 	return client.Do(req)
 }
 
+// Codecs implement caller.CodecNegotiator using plugin's ping response header
+func (r *rest) Codecs(ctx context.Context) ([]string, error) {
+	return r.option.codecs.negotiate(ctx, r.PingContext)
+}
+
 func (r *rest) Ping() (string, error) {
-	endpoint := fmt.Sprintf("%s:%d%s", r.option.Addr, r.option.Port, PathPing)
-	resp, err := r.request("GET", endpoint, nil)
+	return r.PingContext(context.Background())
+}
+
+func (r *rest) PingContext(ctx context.Context) (string, error) {
+	endpoint := r.option.endpoint(PathPing)
+	resp, err := r.request(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return "", err
 	}
@@ -118,6 +329,9 @@ func (r *rest) Ping() (string, error) {
 		return "", errs.ErrPluginPing
 	}
 
+	r.option.negotiate(resp.Header)
+	r.option.codecs.set(codec.Parse(resp.Header.Get(codec.HeaderCodecs)))
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("%w: %q", errs.ErrPluginCall, err)
@@ -133,7 +347,27 @@ func (r *rest) Ping() (string, error) {
 }
 
 func (r *rest) Exec(cmdName string, payload []byte) ([]byte, error) {
-	endpoint := fmt.Sprintf("%s:%d%s", r.option.Addr, r.option.Port, PathExec)
+	return r.ExecContext(context.Background(), cmdName, payload)
+}
+
+func (r *rest) ExecContext(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	encoding, err := r.encoding(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case EncodingBinary:
+		return r.execBinary(ctx, cmdName, payload)
+	case EncodingBase64:
+		return r.execBase64(ctx, cmdName, payload)
+	}
+
+	return r.execHex(ctx, cmdName, payload)
+}
+
+func (r *rest) execHex(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	endpoint := r.option.endpoint(PathExec)
 	p := JSONExecPayload{
 		Cmd:     cmdName,
 		Payload: hex.EncodeToString(payload),
@@ -144,14 +378,14 @@ func (r *rest) Exec(cmdName string, payload []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	resp, err := r.request("POST", endpoint, bytes.NewBuffer(jsonBody))
+	resp, err := r.request(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusAccepted {
-		return nil, errs.ErrPluginExec
+		return nil, execError(resp)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -177,3 +411,60 @@ func (r *rest) Exec(cmdName string, payload []byte) ([]byte, error) {
 
 	return b, nil
 }
+
+// execError used to build errs.PluginError from plugin's JSEND error response,
+// errs.ErrPluginExec will be returned if the response is not a JSEND error
+func execError(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errs.ErrPluginExec
+	}
+
+	var response JSONErrorResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil || (response.Status != "error" && response.Status != "fail") {
+		return errs.ErrPluginExec
+	}
+
+	pluginErr := &errs.PluginError{
+		Message:   response.Message,
+		Details:   errorDetails(response.Data),
+		Retryable: response.Retryable,
+	}
+
+	if response.Code != nil {
+		pluginErr.Code = fmt.Sprintf("%v", response.Code)
+	}
+
+	return pluginErr
+}
+
+// errorDetails used to convert JSEND error's data into error details, an object's
+// string values kept as they are while others encoded as json.  Data which is not
+// an object will be kept under "data" key
+func errorDetails(data interface{}) map[string]string {
+	if data == nil {
+		return nil
+	}
+
+	encode := func(v interface{}) string {
+		if s, ok := v.(string); ok {
+			return s
+		}
+
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return map[string]string{"data": encode(data)}
+	}
+
+	details := make(map[string]string, len(obj))
+	for key, value := range obj {
+		details[key] = encode(value)
+	}
+
+	return details
+}
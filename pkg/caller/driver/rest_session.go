@@ -0,0 +1,169 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/quadroops/goplugin/pkg/callback"
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/codec"
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// PathSession used to open a websocket session
+	PathSession = "/session"
+
+	// SessionCloseSend used as websocket's text message sent by host to tell plugin that
+	// host will not send any messages anymore, all data will be sent as binary messages
+	SessionCloseSend = "close_send"
+)
+
+// Session implement caller.SessionCaller using websocket, the command will be sent
+// as query string.  Plugin should end the session by sending a normal close message,
+// any other close codes will be treated as an error
+func (r *rest) Session(ctx context.Context, cmdName string) (caller.Session, error) {
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: time.Duration(r.option.Timeout) * time.Second,
+	}
+
+	_, _, socket, tlsOpts := r.option.settings()
+	if tlsOpts != nil {
+		tlsConf, err := tlsOpts.Config()
+		if err != nil {
+			return nil, err
+		}
+
+		dialer.TLSClientConfig = tlsConf
+	}
+
+	if socket != "" {
+		dialer.NetDialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	endpoint := fmt.Sprintf("%s?command=%s", r.wsEndpoint(PathSession), url.QueryEscape(cmdName))
+	header := make(http.Header)
+	if chain := callback.CallChain(ctx); len(chain) > 0 {
+		header.Set(callback.HeaderCallChain, callback.FormatCallChain(chain))
+	}
+
+	if contentType := codec.ContentType(ctx); contentType != "" {
+		header.Set(codec.HeaderContentType, contentType)
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, endpoint, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("%w: %q, status code: %d", errs.ErrPluginExec, err, resp.StatusCode)
+		}
+
+		return nil, fmt.Errorf("%w: %q", errs.ErrProtocolRESTRequest, err)
+	}
+
+	s := &wsSession{
+		conn: conn,
+		done: make(chan struct{}),
+	}
+
+	// the connection should be closed as soon as the context cancelled
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.done:
+		}
+	}()
+
+	return s, nil
+}
+
+// wsEndpoint used to build websocket's endpoint for given path
+func (r *rest) wsEndpoint(path string) string {
+	endpoint := r.option.endpoint(path)
+	switch {
+	case strings.HasPrefix(endpoint, "https://"):
+		return fmt.Sprintf("wss://%s", strings.TrimPrefix(endpoint, "https://"))
+	case strings.HasPrefix(endpoint, "http://"):
+		return fmt.Sprintf("ws://%s", strings.TrimPrefix(endpoint, "http://"))
+	case r.option.GetTLS() != nil:
+		return fmt.Sprintf("wss://%s", endpoint)
+	}
+
+	return fmt.Sprintf("ws://%s", endpoint)
+}
+
+type wsSession struct {
+	conn *websocket.Conn
+
+	// websocket doesn't support concurrent writers
+	wmutex sync.Mutex
+	once   sync.Once
+	done   chan struct{}
+}
+
+func (s *wsSession) write(messageType int, data []byte) error {
+	s.wmutex.Lock()
+	defer s.wmutex.Unlock()
+
+	err := s.conn.WriteMessage(messageType, data)
+	if err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return nil
+}
+
+func (s *wsSession) Send(data []byte) error {
+	return s.write(websocket.BinaryMessage, data)
+}
+
+func (s *wsSession) Recv() ([]byte, error) {
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			return nil, io.EOF
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+		}
+
+		if messageType == websocket.BinaryMessage {
+			return data, nil
+		}
+	}
+}
+
+func (s *wsSession) CloseSend() error {
+	return s.write(websocket.TextMessage, []byte(SessionCloseSend))
+}
+
+func (s *wsSession) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+
+		// control message can be written concurrently with other messages,
+		// so a blocked Send will not block the session from being closed
+		s.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+
+		err = s.conn.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,135 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+// createSessionServer used to act as plugin's websocket session, it will reply all
+// binary messages in upper case, and send the total of received messages after
+// host's half-close
+func createSessionServer(t *testing.T) (caller.SessionCaller, *httptest.Server) {
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, driver.PathSession, r.URL.Path)
+		command := r.URL.Query().Get("command")
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if command == "fail" {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "failed"))
+			return
+		}
+
+		var total int
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if messageType == websocket.TextMessage && string(data) == driver.SessionCloseSend {
+				conn.WriteMessage(websocket.BinaryMessage, []byte(fmt.Sprintf("%s:%d", command, total)))
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+
+			total++
+			conn.WriteMessage(websocket.BinaryMessage, bytes.ToUpper(data))
+		}
+	}))
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{Addr: host, Port: port})
+	return rest.(caller.SessionCaller), server
+}
+
+func TestRESTSessionSuccess(t *testing.T) {
+	rest, server := createSessionServer(t)
+	defer server.Close()
+
+	session, err := rest.Session(context.Background(), "upper")
+	assert.NoError(t, err)
+	defer session.Close()
+
+	for _, msg := range []string{"a", "b", "c"} {
+		assert.NoError(t, session.Send([]byte(msg)))
+
+		resp, err := session.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(msg), bytes.ToLower(resp))
+	}
+
+	assert.NoError(t, session.CloseSend())
+
+	resp, err := session.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "upper:3", string(resp))
+
+	_, err = session.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRESTSessionClosedWithError(t *testing.T) {
+	rest, server := createSessionServer(t)
+	defer server.Close()
+
+	session, err := rest.Session(context.Background(), "fail")
+	assert.NoError(t, err)
+	defer session.Close()
+
+	_, err = session.Recv()
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+}
+
+func TestRESTSessionContextCancelled(t *testing.T) {
+	rest, server := createSessionServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session, err := rest.Session(ctx, "upper")
+	assert.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := session.Recv()
+		errCh <- err
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("session should be closed after context cancelled")
+	}
+}
+
+func TestRESTSessionNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{Addr: host, Port: port})
+
+	_, err := rest.(caller.SessionCaller).Session(context.Background(), "upper")
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+}
@@ -0,0 +1,169 @@
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// ContentTypeEventStream used by plugin to send its response as server-sent events,
+	// each event's data is a hex encoded chunk and an "error" event used to stop the stream
+	ContentTypeEventStream = "text/event-stream"
+
+	// streamChunkSize used as maximum chunk's size when reading a chunked response
+	streamChunkSize = 32 * 1024
+
+	// maxEventSize used to limit a single server-sent event's line length
+	maxEventSize = 16 * 1024 * 1024
+)
+
+// ExecStream implement caller.StreamCaller.  Plugin's response can be sent as chunked
+// body which will be read as raw chunks, or as server-sent events.  The stream will not
+// be limited by options's timeout, use given context to limit it
+func (r *rest) ExecStream(ctx context.Context, cmdName string, payload []byte) (caller.Stream, error) {
+	jsonBody, err := json.Marshal(JSONExecPayload{
+		Cmd:     cmdName,
+		Payload: hex.EncodeToString(payload),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := r.newRequest(ctx, "POST", r.option.endpoint(PathExecStream), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", fmt.Sprintf("%s, application/octet-stream", ContentTypeEventStream))
+
+	client, err := r.option.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	// client's timeout includes reading the body, it should not
+	// be applied for a long running stream
+	streamClient := *client
+	streamClient.Timeout = 0
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		defer resp.Body.Close()
+		return nil, execError(resp)
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), ContentTypeEventStream) {
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, streamChunkSize), maxEventSize)
+		return &eventStream{body: resp.Body, scanner: scanner}, nil
+	}
+
+	return &chunkStream{body: resp.Body, buf: make([]byte, streamChunkSize)}, nil
+}
+
+type chunkStream struct {
+	body io.ReadCloser
+	buf  []byte
+}
+
+func (s *chunkStream) Recv() ([]byte, error) {
+	n, err := s.body.Read(s.buf)
+	if n > 0 {
+		chunk := make([]byte, n)
+		copy(chunk, s.buf[:n])
+		return chunk, nil
+	}
+
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return []byte{}, nil
+}
+
+func (s *chunkStream) Close() error {
+	return s.body.Close()
+}
+
+type eventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+func (s *eventStream) Recv() ([]byte, error) {
+	var event string
+	var data []string
+
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+
+		// an empty line used to dispatch the event
+		if line == "" {
+			if len(data) < 1 {
+				event = ""
+				continue
+			}
+
+			return s.dispatch(event, strings.Join(data, ""))
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	// last event may not be followed by an empty line
+	if len(data) > 0 {
+		return s.dispatch(event, strings.Join(data, ""))
+	}
+
+	return nil, io.EOF
+}
+
+func (s *eventStream) dispatch(event, data string) ([]byte, error) {
+	if event == "error" {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, data)
+	}
+
+	chunk, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return chunk, nil
+}
+
+func (s *eventStream) Close() error {
+	return s.body.Close()
+}
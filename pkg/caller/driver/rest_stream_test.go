@@ -0,0 +1,105 @@
+package driver_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func readStream(stream caller.Stream) ([]string, error) {
+	defer stream.Close()
+
+	var chunks []string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return chunks, nil
+		}
+
+		if err != nil {
+			return chunks, err
+		}
+
+		chunks = append(chunks, string(chunk))
+	}
+}
+
+func createStreamREST(handler http.HandlerFunc) (caller.StreamCaller, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{Addr: host, Port: port})
+	return rest.(caller.StreamCaller), server
+}
+
+func TestRESTExecStreamChunked(t *testing.T) {
+	rest, server := createStreamREST(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, driver.PathExecStream, r.URL.Path)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "line-%d\n", i)
+			w.(http.Flusher).Flush()
+		}
+	})
+	defer server.Close()
+
+	stream, err := rest.ExecStream(context.Background(), "test", []byte("hello"))
+	assert.NoError(t, err)
+
+	chunks, err := readStream(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "line-0\nline-1\nline-2\n", strings.Join(chunks, ""))
+}
+
+func TestRESTExecStreamEvents(t *testing.T) {
+	rest, server := createStreamREST(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", driver.ContentTypeEventStream)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, ": comment\ndata: %s\n\n", hex.EncodeToString([]byte(fmt.Sprintf("chunk-%d", i))))
+			w.(http.Flusher).Flush()
+		}
+	})
+	defer server.Close()
+
+	stream, err := rest.ExecStream(context.Background(), "test", []byte("hello"))
+	assert.NoError(t, err)
+
+	chunks, err := readStream(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chunk-0", "chunk-1", "chunk-2"}, chunks)
+}
+
+func TestRESTExecStreamErrorEvent(t *testing.T) {
+	rest, server := createStreamREST(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", driver.ContentTypeEventStream)
+		fmt.Fprintf(w, "data: %s\n\nevent: error\ndata: disk full\n\n", hex.EncodeToString([]byte("chunk")))
+	})
+	defer server.Close()
+
+	stream, err := rest.ExecStream(context.Background(), "test", []byte("hello"))
+	assert.NoError(t, err)
+
+	chunks, err := readStream(stream)
+	assert.Equal(t, []string{"chunk"}, chunks)
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+}
+
+func TestRESTExecStreamStatusCode(t *testing.T) {
+	rest, server := createStreamREST(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	_, err := rest.ExecStream(context.Background(), "test", []byte("hello"))
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+}
@@ -1,6 +1,7 @@
 package driver_test
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -11,8 +12,12 @@ import (
 	"net/url"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/quadroops/goplugin/pkg/callback"
+	"github.com/quadroops/goplugin/pkg/caller"
 	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/codec"
 	"github.com/quadroops/goplugin/pkg/errs"
 	"github.com/stretchr/testify/assert"
 	"github.com/vmihailenco/msgpack/v5"
@@ -197,3 +202,152 @@ func TestExecErrorStatusCode(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, errs.ErrPluginExec))
 }
+
+func TestExecContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr: host,
+		Port: port,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := rest.ExecContext(ctx, "rest.testing", []byte("test"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestExecMetadataHeaders(t *testing.T) {
+	var chain, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chain = r.Header.Get(callback.HeaderCallChain)
+		contentType = r.Header.Get(codec.HeaderContentType)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(driver.JSONResponse{
+			Status: "success",
+			Data:   driver.JSONData{Response: hex.EncodeToString([]byte("test"))},
+		})
+	}))
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr: host,
+		Port: port,
+	})
+
+	ctx := callback.WithCallChain(context.Background(), []string{"plugin_1", "plugin_2"})
+	ctx = codec.WithContentType(ctx, codec.Msgpack.ContentType())
+	_, err := rest.ExecContext(ctx, "rest.testing", []byte("test"))
+	assert.NoError(t, err)
+	assert.Equal(t, "plugin_1,plugin_2", chain)
+	assert.Equal(t, "application/msgpack", contentType)
+}
+
+func TestExecPluginError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(driver.JSONErrorResponse{
+			Status:    "error",
+			Message:   "user not found",
+			Code:      404,
+			Data:      map[string]string{"id": "1"},
+			Retryable: true,
+		})
+	}))
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	for _, encoding := range []string{driver.EncodingHex, driver.EncodingBase64, driver.EncodingBinary} {
+		rest := driver.NewREST(&driver.RESTOptions{
+			Addr:     host,
+			Port:     port,
+			Encoding: encoding,
+		})
+
+		_, err := rest.Exec("rest.testing", []byte("test"))
+		assert.True(t, errors.Is(err, errs.ErrPluginExec))
+
+		var pluginErr *errs.PluginError
+		assert.True(t, errors.As(err, &pluginErr), encoding)
+		assert.Equal(t, &errs.PluginError{
+			Code:      "404",
+			Message:   "user not found",
+			Details:   map[string]string{"id": "1"},
+			Retryable: true,
+		}, pluginErr)
+	}
+}
+
+func TestExecPluginFailData(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    interface{}
+		details map[string]string
+	}{
+		{"object", map[string]interface{}{"email": "required", "age": 17, "tags": []string{"a"}}, map[string]string{"email": "required", "age": "17", "tags": `["a"]`}},
+		{"list", []string{"email", "age"}, map[string]string{"data": `["email","age"]`}},
+		{"string", "invalid payload", map[string]string{"data": "invalid payload"}},
+		{"empty", nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"status":  "fail",
+					"message": "invalid request",
+					"data":    tc.data,
+				})
+			}))
+			defer server.Close()
+
+			host, port := gethostport(server.URL)
+			rest := driver.NewREST(&driver.RESTOptions{
+				Addr: host,
+				Port: port,
+			})
+
+			_, err := rest.Exec("rest.testing", []byte("test"))
+			assert.True(t, errors.Is(err, errs.ErrPluginExec))
+
+			var pluginErr *errs.PluginError
+			assert.True(t, errors.As(err, &pluginErr))
+			assert.Equal(t, "invalid request", pluginErr.Message)
+			assert.Equal(t, tc.details, pluginErr.Details)
+		})
+	}
+}
+
+func TestRESTCodecs(t *testing.T) {
+	pings := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings++
+		w.Header().Set(codec.HeaderCodecs, "msgpack, json")
+		json.NewEncoder(w).Encode(driver.JSONResponse{Status: "success", Data: driver.JSONData{Response: "pong"}})
+	}))
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{Addr: host, Port: port})
+	negotiator, ok := rest.(caller.CodecNegotiator)
+	assert.True(t, ok)
+
+	for i := 0; i < 2; i++ {
+		codecs, err := negotiator.Codecs(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"msgpack", "json"}, codecs)
+	}
+
+	assert.Equal(t, 1, pings)
+}
@@ -0,0 +1,74 @@
+package driver
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConns used when transport doesn't define max idle connections
+	DefaultMaxIdleConns = 100
+
+	// DefaultMaxIdleConnsPerHost used when transport doesn't define max idle connections per host
+	DefaultMaxIdleConnsPerHost = 100
+
+	// DefaultIdleConnTimeout used when transport doesn't define idle connection timeout (in seconds)
+	DefaultIdleConnTimeout = 90
+)
+
+// RESTTransport used to configure http transport used by REST caller.  A transport
+// can be shared between multiple plugins, their connections will be pooled by
+// the same transport.  If RoundTripper defined, all other options will be ignored
+type RESTTransport struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     int
+	RoundTripper        http.RoundTripper
+
+	once sync.Once
+	rt   http.RoundTripper
+}
+
+type idleCloser interface {
+	CloseIdleConnections()
+}
+
+// Get used to get transport's round tripper, it will be built only once
+func (t *RESTTransport) Get() http.RoundTripper {
+	t.once.Do(func() {
+		if t.RoundTripper != nil {
+			t.rt = t.RoundTripper
+			return
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = DefaultMaxIdleConns
+		transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+		transport.IdleConnTimeout = time.Duration(DefaultIdleConnTimeout) * time.Second
+
+		if t.MaxIdleConns > 0 {
+			transport.MaxIdleConns = t.MaxIdleConns
+		}
+
+		if t.MaxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+		}
+
+		if t.IdleConnTimeout > 0 {
+			transport.IdleConnTimeout = time.Duration(t.IdleConnTimeout) * time.Second
+		}
+
+		t.rt = transport
+	})
+
+	return t.rt
+}
+
+// CloseIdleConnections used to close all idle connections, if the round tripper
+// support it
+func (t *RESTTransport) CloseIdleConnections() {
+	if closer, ok := t.Get().(idleCloser); ok {
+		closer.CloseIdleConnections()
+	}
+}
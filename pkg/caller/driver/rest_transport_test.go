@@ -0,0 +1,111 @@
+package driver_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingRoundTripper struct {
+	count int
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRESTClientReused(t *testing.T) {
+	opts := &driver.RESTOptions{
+		Addr: "localhost",
+		Port: 8080,
+	}
+
+	driver.NewREST(opts)
+	client1, err := opts.Client()
+	assert.NoError(t, err)
+
+	driver.NewREST(opts)
+	client2, err := opts.Client()
+	assert.NoError(t, err)
+	assert.Same(t, client1, client2)
+	assert.NoError(t, opts.Close())
+}
+
+func TestRESTTransportShared(t *testing.T) {
+	transport := &driver.RESTTransport{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 5,
+		IdleConnTimeout:     30,
+	}
+
+	client1, err := (&driver.RESTOptions{Transport: transport}).Client()
+	assert.NoError(t, err)
+
+	client2, err := (&driver.RESTOptions{Transport: transport}).Client()
+	assert.NoError(t, err)
+	assert.Same(t, client1.Transport, client2.Transport)
+
+	rt, ok := transport.Get().(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, 10, rt.MaxIdleConns)
+	assert.Equal(t, 5, rt.MaxIdleConnsPerHost)
+}
+
+func TestRESTCustomRoundTripper(t *testing.T) {
+	server := createServerPing(driver.JSONResponse{
+		Status: "success",
+		Data: driver.JSONData{
+			Response: "pong",
+		},
+	}, http.StatusOK)
+	defer server.Close()
+
+	rt := &countingRoundTripper{}
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr: host,
+		Port: port,
+		Transport: &driver.RESTTransport{
+			RoundTripper: rt,
+		},
+	})
+
+	_, err := rest.Ping()
+	assert.NoError(t, err)
+	_, err = rest.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rt.count)
+}
+
+func TestRESTOptionsChangedWhileCalling(t *testing.T) {
+	server := createServerPing(driver.JSONResponse{
+		Status: "success",
+		Data:   driver.JSONData{Response: "pong"},
+	}, http.StatusOK)
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	opts := &driver.RESTOptions{Addr: host, Port: port}
+	rest := driver.NewREST(opts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rest.Ping()
+			assert.NoError(t, err)
+		}()
+	}
+
+	for i := 0; i < 5; i++ {
+		opts.SetAddress(host, port)
+		opts.SetTLS(nil)
+	}
+
+	wg.Wait()
+}
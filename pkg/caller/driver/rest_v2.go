@@ -0,0 +1,167 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// PathExecV2 used to send exec request using rest protocol v2
+	PathExecV2 = "/v2/exec"
+
+	// HeaderCommand used to send exec's command when sending binary payload
+	HeaderCommand = "X-Goplugin-Command"
+
+	// HeaderEncodings used by plugin's ping response to advertise supported payload encodings
+	HeaderEncodings = "X-Goplugin-Encodings"
+
+	// ContentTypeOctetStream used as binary payload's content type
+	ContentTypeOctetStream = "application/octet-stream"
+
+	// EncodingHex used to send hex payload in json to PathExec, supported by all plugins
+	EncodingHex = "hex"
+
+	// EncodingBase64 used to send base64 payload in json to PathExecV2
+	EncodingBase64 = "base64"
+
+	// EncodingBinary used to send raw payload to PathExecV2
+	EncodingBinary = "binary"
+)
+
+// JSONExecPayloadV2 used as main payload when sending base64 exec request
+type JSONExecPayloadV2 struct {
+	Cmd     string `json:"command"`
+	Payload []byte `json:"payload"`
+}
+
+// JSONDataV2 used as base64 exec's response
+type JSONDataV2 struct {
+	Response []byte `json:"response"`
+}
+
+// JSONResponseV2 following JSEND standard as base64 exec's response
+type JSONResponseV2 struct {
+	Status string     `json:"status"`
+	Data   JSONDataV2 `json:"data"`
+}
+
+// encoding used to get payload's encoding, it will be negotiated using plugin's ping
+// response if the options doesn't define any encodings
+func (r *rest) encoding(ctx context.Context) (string, error) {
+	switch r.option.Encoding {
+	case EncodingHex, EncodingBase64, EncodingBinary:
+		return r.option.Encoding, nil
+	case "":
+	default:
+		return "", fmt.Errorf("%w: unknown rest encoding %s", errs.ErrProtocolOptions, r.option.Encoding)
+	}
+
+	if encoding := r.option.negotiatedEncoding(); encoding != "" {
+		return encoding, nil
+	}
+
+	// plugin that cannot be pinged will be treated as a hex plugin for this request
+	// only, the encoding will be negotiated again on next request
+	r.PingContext(ctx)
+	if encoding := r.option.negotiatedEncoding(); encoding != "" {
+		return encoding, nil
+	}
+
+	return EncodingHex, nil
+}
+
+func (o *RESTOptions) negotiatedEncoding() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.negotiated
+}
+
+// negotiate used to pick the best encoding advertised by plugin's ping response,
+// plugins without any advertised encodings will be treated as hex plugins
+func (o *RESTOptions) negotiate(header http.Header) {
+	encoding := EncodingHex
+	supported := make(map[string]bool)
+	for _, e := range strings.Split(header.Get(HeaderEncodings), ",") {
+		supported[strings.TrimSpace(e)] = true
+	}
+
+	switch {
+	case supported[EncodingBinary]:
+		encoding = EncodingBinary
+	case supported[EncodingBase64]:
+		encoding = EncodingBase64
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.negotiated = encoding
+}
+
+func (r *rest) execBinary(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	req, err := r.newRequest(ctx, "POST", r.option.endpoint(PathExecV2), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", ContentTypeOctetStream)
+	req.Header.Set(HeaderCommand, cmdName)
+
+	client, err := r.option.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, execError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginCall, err)
+	}
+
+	return body, nil
+}
+
+func (r *rest) execBase64(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	jsonBody, err := json.Marshal(JSONExecPayloadV2{
+		Cmd:     cmdName,
+		Payload: payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.request(ctx, "POST", r.option.endpoint(PathExecV2), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, execError(resp)
+	}
+
+	var response JSONResponseV2
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginCall, err)
+	}
+
+	return response.Data.Response, nil
+}
@@ -0,0 +1,137 @@
+package driver_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+// createServerV2 used to create plugin's server supporting given encodings, it
+// echoes the command and payload back
+func createServerV2(encodings string, paths map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths[r.URL.Path]++
+
+		switch r.URL.Path {
+		case driver.PathPing:
+			if encodings != "" {
+				w.Header().Set(driver.HeaderEncodings, encodings)
+			}
+
+			json.NewEncoder(w).Encode(driver.JSONResponse{Status: "success", Data: driver.JSONData{Response: "pong"}})
+		case driver.PathExecV2:
+			if r.Header.Get("Content-Type") == driver.ContentTypeOctetStream {
+				body, _ := ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusAccepted)
+				w.Write(append([]byte(r.Header.Get(driver.HeaderCommand)+":"), body...))
+				return
+			}
+
+			var p driver.JSONExecPayloadV2
+			json.NewDecoder(r.Body).Decode(&p)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(driver.JSONResponseV2{
+				Status: "success",
+				Data:   driver.JSONDataV2{Response: append([]byte(p.Cmd+":"), p.Payload...)},
+			})
+		case driver.PathExec:
+			var p driver.JSONExecPayload
+			json.NewDecoder(r.Body).Decode(&p)
+			payload, _ := hex.DecodeString(p.Payload)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(driver.JSONResponse{
+				Status: "success",
+				Data:   driver.JSONData{Response: hex.EncodeToString(append([]byte(p.Cmd+":"), payload...))},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestExecNegotiateEncodings(t *testing.T) {
+	cases := map[string]string{
+		"binary, base64": driver.PathExecV2,
+		"base64":         driver.PathExecV2,
+		"":               driver.PathExec,
+	}
+
+	for encodings, path := range cases {
+		paths := make(map[string]int)
+		server := createServerV2(encodings, paths)
+
+		host, port := gethostport(server.URL)
+		rest := driver.NewREST(&driver.RESTOptions{
+			Addr: host,
+			Port: port,
+		})
+
+		for i := 0; i < 2; i++ {
+			resp, err := rest.Exec("test", []byte{0, 1, 2})
+			assert.NoError(t, err)
+			assert.Equal(t, append([]byte("test:"), 0, 1, 2), resp)
+		}
+
+		// encoding negotiated once
+		assert.Equal(t, 1, paths[driver.PathPing], encodings)
+		assert.Equal(t, 2, paths[path], encodings)
+		server.Close()
+	}
+}
+
+func TestExecExplicitEncoding(t *testing.T) {
+	paths := make(map[string]int)
+	server := createServerV2("binary", paths)
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr:     host,
+		Port:     port,
+		Encoding: driver.EncodingHex,
+	})
+
+	resp, err := rest.Exec("test", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test:hello"), resp)
+	assert.Equal(t, 0, paths[driver.PathPing])
+	assert.Equal(t, 1, paths[driver.PathExec])
+}
+
+func TestExecUnknownEncoding(t *testing.T) {
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr:     "localhost",
+		Port:     8080,
+		Encoding: "unknown",
+	})
+
+	_, err := rest.Exec("test", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolOptions))
+}
+
+func TestExecBinaryErrorStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	host, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr:     host,
+		Port:     port,
+		Encoding: driver.EncodingBinary,
+	})
+
+	_, err := rest.Exec("test", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+}
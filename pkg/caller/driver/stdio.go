@@ -0,0 +1,414 @@
+package driver
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/callback"
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/codec"
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// JSONRPCVersion used as jsonrpc's version of all stdio requests
+	JSONRPCVersion = "2.0"
+
+	// MethodPing used as jsonrpc's method when sending ping request
+	MethodPing = "ping"
+
+	// MethodExec used as jsonrpc's method when sending exec request
+	MethodExec = "exec"
+
+	// maxStdioMessage used to limit a single response's line length
+	maxStdioMessage = 16 * 1024 * 1024
+)
+
+// JSONRPCRequest used as a single newline-delimited request sent to plugin's stdin
+type JSONRPCRequest struct {
+	Version     string           `json:"jsonrpc"`
+	ID          uint64           `json:"id"`
+	Method      string           `json:"method"`
+	Params      *JSONExecPayload `json:"params,omitempty"`
+	Chain       []string         `json:"call_chain,omitempty"`
+	ContentType string           `json:"content_type,omitempty"`
+}
+
+// JSONRPCError used as jsonrpc's error object, Data used as plugin's structured error
+type JSONRPCError struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    *JSONRPCErrorData `json:"data,omitempty"`
+}
+
+// JSONRPCErrorData used as jsonrpc error's data, Code will be used as error's code
+// instead of jsonrpc's code if it's defined
+type JSONRPCErrorData struct {
+	Code      string            `json:"code,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	Retryable bool              `json:"retryable,omitempty"`
+}
+
+// JSONRPCResponse used as a single newline-delimited response read from plugin's stdout,
+// Codecs used by ping's response to advertise plugin's supported codecs
+type JSONRPCResponse struct {
+	Version string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Result  string        `json:"result"`
+	Error   *JSONRPCError `json:"error,omitempty"`
+	Codecs  []string      `json:"codecs,omitempty"`
+}
+
+// StdioOptions used to configure stdio caller.  Conn is plugin's stdin and stdout pipes,
+// it will be attached automatically when plugin started by pipe runner.  All callers
+// using the same options will share a single connection, concurrent requests will be
+// matched to their responses by jsonrpc's id
+type StdioOptions struct {
+	Conn    io.ReadWriter
+	Timeout int
+
+	mutex  sync.Mutex
+	client *stdioClient
+	codecs codecs
+}
+
+// Attach used to replace current connection, all pending requests on previous
+// connection will be cancelled.  Attaching the same connection will do nothing
+func (o *StdioOptions) Attach(conn io.ReadWriter) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.Conn == conn {
+		return
+	}
+
+	if o.client != nil {
+		o.client.close(errs.ErrProtocolStdioClosed)
+		o.client = nil
+	}
+
+	o.Conn = conn
+}
+
+// Close used to cancel all pending requests, the connection itself owned by
+// plugin's process and will be closed when the process killed
+func (o *StdioOptions) Close() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.client != nil {
+		o.client.close(errs.ErrProtocolStdioClosed)
+		o.client = nil
+	}
+
+	o.Conn = nil
+	return nil
+}
+
+func (o *StdioOptions) getClient() (*stdioClient, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.Conn == nil {
+		return nil, errs.ErrProtocolStdioClosed
+	}
+
+	if o.client == nil || o.client.isClosed() {
+		o.client = newStdioClient(o.Conn)
+	}
+
+	return o.client, nil
+}
+
+type stdioClient struct {
+	mutex   sync.Mutex
+	writes  chan stdioWrite
+	done    chan struct{}
+	nextID  uint64
+	pending map[uint64]chan JSONRPCResponse
+	err     error
+}
+
+// stdioWrite used to send a request's message to the writer, the result
+// will be sent back using its own channel
+type stdioWrite struct {
+	message []byte
+	result  chan error
+}
+
+func newStdioClient(conn io.ReadWriter) *stdioClient {
+	c := &stdioClient{
+		writes:  make(chan stdioWrite),
+		done:    make(chan struct{}),
+		pending: make(map[uint64]chan JSONRPCResponse),
+	}
+
+	go c.read(conn)
+	go c.write(conn)
+	return c
+}
+
+// write used to write all messages one at a time so they're never interleaved,
+// a blocked pipe only blocks this goroutine and the callers may give up waiting
+func (c *stdioClient) write(w io.Writer) {
+	for {
+		select {
+		case req := <-c.writes:
+			_, err := w.Write(req.message)
+			req.result <- err
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// send used to pass given message to the writer, it will stop waiting
+// when the context is done or the client closed
+func (c *stdioClient) send(ctx context.Context, message []byte) error {
+	req := stdioWrite{
+		message: message,
+		result:  make(chan error, 1),
+	}
+
+	select {
+	case c.writes <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.closedErr()
+	}
+
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *stdioClient) read(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStdioMessage)
+
+	for scanner.Scan() {
+		var resp JSONRPCResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			// plugin may print anything else than jsonrpc's response
+			continue
+		}
+
+		c.mutex.Lock()
+		ch, exist := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mutex.Unlock()
+
+		if exist {
+			ch <- resp
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+
+	c.close(fmt.Errorf("%w: %q", errs.ErrProtocolStdioClosed, err))
+}
+
+func (c *stdioClient) isClosed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err != nil
+}
+
+func (c *stdioClient) close(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+	close(c.done)
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func (c *stdioClient) call(ctx context.Context, method string, params *JSONExecPayload) (JSONRPCResponse, error) {
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return JSONRPCResponse{}, c.err
+	}
+
+	c.nextID++
+	id := c.nextID
+	ch := make(chan JSONRPCResponse, 1)
+	c.pending[id] = ch
+	c.mutex.Unlock()
+
+	b, err := json.Marshal(JSONRPCRequest{
+		Version:     JSONRPCVersion,
+		ID:          id,
+		Method:      method,
+		Params:      params,
+		Chain:       callback.CallChain(ctx),
+		ContentType: codec.ContentType(ctx),
+	})
+
+	if err == nil {
+		err = c.send(ctx, append(b, '\n'))
+	}
+
+	if err != nil {
+		c.mutex.Lock()
+		delete(c.pending, id)
+		c.mutex.Unlock()
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return JSONRPCResponse{}, ctxErr
+		}
+
+		return JSONRPCResponse{}, fmt.Errorf("%w: %q", errs.ErrPluginCall, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return JSONRPCResponse{}, c.closedErr()
+		}
+
+		return resp, nil
+	case <-ctx.Done():
+		c.mutex.Lock()
+		delete(c.pending, id)
+		c.mutex.Unlock()
+
+		return JSONRPCResponse{}, ctx.Err()
+	}
+}
+
+func (c *stdioClient) closedErr() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+type stdio struct {
+	option *StdioOptions
+}
+
+// NewStdio used to create new instance of stdio caller, it will talk to the plugin
+// using newline-delimited jsonrpc over plugin's stdin and stdout
+func NewStdio(o *StdioOptions) caller.Caller {
+	opt := o
+	if opt == nil {
+		opt = &StdioOptions{}
+	}
+
+	// override timeout if less than 1s or not defined
+	if opt.Timeout == 0 {
+		opt.Timeout = Timeout
+	}
+
+	return &stdio{opt}
+}
+
+func (s *stdio) call(ctx context.Context, method string, params *JSONExecPayload) (string, error) {
+	client, err := s.option.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.option.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := client.call(ctx, method, params)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Error != nil {
+		return "", resp.Error.pluginError()
+	}
+
+	if method == MethodPing {
+		s.option.codecs.set(resp.Codecs)
+	}
+
+	return resp.Result, nil
+}
+
+// Codecs implement caller.CodecNegotiator using plugin's ping response
+func (s *stdio) Codecs(ctx context.Context) ([]string, error) {
+	return s.option.codecs.negotiate(ctx, s.PingContext)
+}
+
+func (s *stdio) Ping() (string, error) {
+	return s.PingContext(context.Background())
+}
+
+func (s *stdio) PingContext(ctx context.Context) (string, error) {
+	resp, err := s.call(ctx, MethodPing, nil)
+	if err != nil {
+		if errors.Is(err, errs.ErrPluginExec) {
+			return "", fmt.Errorf("%w: %q", errs.ErrPluginPing, err)
+		}
+
+		return "", err
+	}
+
+	return resp, nil
+}
+
+func (s *stdio) Exec(cmdName string, payload []byte) ([]byte, error) {
+	return s.ExecContext(context.Background(), cmdName, payload)
+}
+
+func (s *stdio) ExecContext(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	resp, err := s.call(ctx, MethodExec, &JSONExecPayload{
+		Cmd:     cmdName,
+		Payload: hex.EncodeToString(payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := hex.DecodeString(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, err)
+	}
+
+	return b, nil
+}
+
+func (e *JSONRPCError) pluginError() *errs.PluginError {
+	pluginErr := &errs.PluginError{
+		Code:    strconv.Itoa(e.Code),
+		Message: e.Message,
+	}
+
+	if e.Data != nil {
+		if e.Data.Code != "" {
+			pluginErr.Code = e.Data.Code
+		}
+
+		pluginErr.Details = e.Data.Details
+		pluginErr.Retryable = e.Data.Retryable
+	}
+
+	return pluginErr
+}
@@ -0,0 +1,170 @@
+package driver_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type stdioConn struct {
+	io.Reader
+	io.Writer
+}
+
+// createStdioPlugin used to act as plugin's side, it will reply all requests
+// using given handler, responses will be matched to requests by their id
+func createStdioPlugin(handler func(req driver.JSONRPCRequest) driver.JSONRPCResponse) (*stdioConn, func()) {
+	hostReader, pluginWriter := io.Pipe()
+	pluginReader, hostWriter := io.Pipe()
+
+	go func() {
+		scanner := bufio.NewScanner(pluginReader)
+		for scanner.Scan() {
+			var req driver.JSONRPCRequest
+			json.Unmarshal(scanner.Bytes(), &req)
+
+			resp := handler(req)
+			resp.Version = driver.JSONRPCVersion
+			resp.ID = req.ID
+
+			// unrelated output should be ignored
+			fmt.Fprintln(pluginWriter, "debug: request received")
+
+			b, _ := json.Marshal(resp)
+			pluginWriter.Write(append(b, '\n'))
+		}
+	}()
+
+	closer := func() {
+		pluginWriter.Close()
+		pluginReader.Close()
+	}
+
+	return &stdioConn{hostReader, hostWriter}, closer
+}
+
+func TestStdioPingSuccess(t *testing.T) {
+	conn, closer := createStdioPlugin(func(req driver.JSONRPCRequest) driver.JSONRPCResponse {
+		return driver.JSONRPCResponse{Result: req.Method}
+	})
+	defer closer()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: conn})
+	resp, err := stdio.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, driver.MethodPing, resp)
+}
+
+func TestStdioExecSuccess(t *testing.T) {
+	conn, closer := createStdioPlugin(func(req driver.JSONRPCRequest) driver.JSONRPCResponse {
+		payload, _ := hex.DecodeString(req.Params.Payload)
+		return driver.JSONRPCResponse{Result: hex.EncodeToString([]byte(req.Params.Cmd + ":" + string(payload)))}
+	})
+	defer closer()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: conn})
+	for i := 0; i < 5; i++ {
+		resp, err := stdio.Exec("test", []byte(fmt.Sprintf("%d", i)))
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("test:%d", i), string(resp))
+	}
+}
+
+func TestStdioExecError(t *testing.T) {
+	conn, closer := createStdioPlugin(func(req driver.JSONRPCRequest) driver.JSONRPCResponse {
+		return driver.JSONRPCResponse{Error: &driver.JSONRPCError{Code: -32601, Message: "method not found"}}
+	})
+	defer closer()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: conn})
+	_, err := stdio.Exec("test", []byte("test"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+}
+
+func TestStdioClosed(t *testing.T) {
+	conn, closer := createStdioPlugin(func(req driver.JSONRPCRequest) driver.JSONRPCResponse {
+		return driver.JSONRPCResponse{}
+	})
+	closer()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: conn, Timeout: 1})
+	_, err := stdio.Ping()
+	assert.Error(t, err)
+}
+
+func TestStdioNoConnection(t *testing.T) {
+	stdio := driver.NewStdio(&driver.StdioOptions{})
+	_, err := stdio.Ping()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolStdioClosed))
+}
+
+func TestStdioExecPluginError(t *testing.T) {
+	conn, closer := createStdioPlugin(func(req driver.JSONRPCRequest) driver.JSONRPCResponse {
+		return driver.JSONRPCResponse{Error: &driver.JSONRPCError{
+			Code:    -32000,
+			Message: "user not found",
+			Data: &driver.JSONRPCErrorData{
+				Code:      "NOT_FOUND",
+				Details:   map[string]string{"id": "1"},
+				Retryable: true,
+			},
+		}}
+	})
+	defer closer()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: conn})
+	_, err := stdio.Exec("test", []byte("test"))
+
+	var pluginErr *errs.PluginError
+	assert.True(t, errors.As(err, &pluginErr))
+	assert.Equal(t, &errs.PluginError{
+		Code:      "NOT_FOUND",
+		Message:   "user not found",
+		Details:   map[string]string{"id": "1"},
+		Retryable: true,
+	}, pluginErr)
+}
+
+func TestStdioBlockedWriteCancelled(t *testing.T) {
+	// plugin never reads its stdin, so all writes will be blocked
+	hostReader, pluginWriter := io.Pipe()
+	pluginReader, hostWriter := io.Pipe()
+	defer pluginWriter.Close()
+	defer pluginReader.Close()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: &stdioConn{hostReader, hostWriter}})
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		_, err := stdio.ExecContext(ctx, "test", []byte("test"))
+		cancel()
+
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, time.Since(start) < time.Second)
+	}
+}
+
+func TestStdioCodecs(t *testing.T) {
+	conn, closer := createStdioPlugin(func(req driver.JSONRPCRequest) driver.JSONRPCResponse {
+		return driver.JSONRPCResponse{Result: req.Method, Codecs: []string{"msgpack"}}
+	})
+	defer closer()
+
+	stdio := driver.NewStdio(&driver.StdioOptions{Conn: conn})
+	codecs, err := stdio.(caller.CodecNegotiator).Codecs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"msgpack"}, codecs)
+}
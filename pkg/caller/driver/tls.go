@@ -0,0 +1,129 @@
+package driver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// TLSOptions used to configure TLS connection to plugin.  CAFile used to verify
+// plugin's certificate, if it's empty system's cert pool will be used.  CertFile
+// and KeyFile used as client certificate for mutual TLS.  If Pinned defined, all
+// other options will be ignored
+type TLSOptions struct {
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+	Pinned     *PinnedTLS
+}
+
+// PinnedTLS used to build TLS configurations pinned to plugin's exact certificate.
+// Its materials can be updated every time plugin relaunched, new connections
+// will always use latest materials
+type PinnedTLS struct {
+	mutex  sync.RWMutex
+	cert   *tls.Certificate
+	pinned []byte
+}
+
+// NewPinnedTLS used to create new empty pinned TLS, all connections will be rejected
+// until its materials updated
+func NewPinnedTLS() *PinnedTLS {
+	return &PinnedTLS{}
+}
+
+// Update used to replace client's key pair and pinned plugin's certificate, all
+// materials must be PEM encoded
+func (p *PinnedTLS) Update(clientCert, clientKey, serverCert []byte) error {
+	cert, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrProtocolTLSConfig, err)
+	}
+
+	block, _ := pem.Decode(serverCert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("%w: invalid pinned certificate", errs.ErrProtocolTLSConfig)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.cert = &cert
+	p.pinned = block.Bytes
+	return nil
+}
+
+// Config used to build tls.Config which only trust pinned plugin's certificate
+func (p *PinnedTLS) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+
+		// chain verification is replaced by VerifyPeerCertificate, plugin's
+		// certificate must be exactly the same as pinned certificate
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			p.mutex.RLock()
+			defer p.mutex.RUnlock()
+
+			if p.cert == nil {
+				return nil, fmt.Errorf("%w: empty pinned materials", errs.ErrProtocolTLSConfig)
+			}
+
+			return p.cert, nil
+		},
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			p.mutex.RLock()
+			defer p.mutex.RUnlock()
+
+			if len(rawCerts) < 1 || p.pinned == nil || !bytes.Equal(rawCerts[0], p.pinned) {
+				return fmt.Errorf("%w: plugin's certificate doesn't match pinned certificate", errs.ErrProtocolTLSConfig)
+			}
+
+			return nil
+		},
+	}
+}
+
+// Config used to build tls.Config from current options
+func (t *TLSOptions) Config() (*tls.Config, error) {
+	if t.Pinned != nil {
+		return t.Pinned.Config(), nil
+	}
+
+	conf := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.CAFile != "" {
+		ca, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errs.ErrProtocolTLSConfig, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("%w: invalid ca file %q", errs.ErrProtocolTLSConfig, t.CAFile)
+		}
+
+		conf.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errs.ErrProtocolTLSConfig, err)
+		}
+
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,260 @@
+package driver_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type certFiles struct {
+	dir        string
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverCert tls.Certificate
+	serverPEM  []byte
+	clientPEM  []byte
+	clientKey  []byte
+	pool       *x509.CertPool
+}
+
+func createCert(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return cert, key, certPEM, keyPEM
+}
+
+func createCertFiles(t *testing.T) *certFiles {
+	dir, err := ioutil.TempDir("", "goplugin-tls")
+	assert.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "goplugin-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	ca, caKey, caPEM, _ := createCert(t, caTemplate, nil, nil)
+
+	_, _, serverPEM, serverKeyPEM := createCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "plugin"},
+		DNSNames:     []string{"plugin.local"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+
+	_, _, clientPEM, clientKeyPEM := createCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "host"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	files := &certFiles{
+		dir:       dir,
+		caFile:    filepath.Join(dir, "ca.pem"),
+		certFile:  filepath.Join(dir, "client.pem"),
+		keyFile:   filepath.Join(dir, "client.key"),
+		pool:      x509.NewCertPool(),
+		serverPEM: serverPEM,
+		clientPEM: clientPEM,
+		clientKey: clientKeyPEM,
+	}
+
+	assert.NoError(t, ioutil.WriteFile(files.caFile, caPEM, 0600))
+	assert.NoError(t, ioutil.WriteFile(files.certFile, clientPEM, 0600))
+	assert.NoError(t, ioutil.WriteFile(files.keyFile, clientKeyPEM, 0600))
+
+	files.serverCert, err = tls.X509KeyPair(serverPEM, serverKeyPEM)
+	assert.NoError(t, err)
+
+	files.pool.AddCert(ca)
+	return files
+}
+
+func TestTLSConfigSuccess(t *testing.T) {
+	files := createCertFiles(t)
+	defer os.RemoveAll(files.dir)
+
+	conf, err := (&driver.TLSOptions{
+		CAFile:     files.caFile,
+		CertFile:   files.certFile,
+		KeyFile:    files.keyFile,
+		ServerName: "plugin.local",
+	}).Config()
+
+	assert.NoError(t, err)
+	assert.NotNil(t, conf.RootCAs)
+	assert.Len(t, conf.Certificates, 1)
+	assert.Equal(t, "plugin.local", conf.ServerName)
+}
+
+func TestTLSConfigInvalidCA(t *testing.T) {
+	files := createCertFiles(t)
+	defer os.RemoveAll(files.dir)
+
+	_, err := (&driver.TLSOptions{
+		CAFile: files.keyFile,
+	}).Config()
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolTLSConfig))
+
+	_, err = (&driver.TLSOptions{
+		CAFile: filepath.Join(files.dir, "unknown.pem"),
+	}).Config()
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolTLSConfig))
+}
+
+func TestRESTPingMutualTLS(t *testing.T) {
+	files := createCertFiles(t)
+	defer os.RemoveAll(files.dir)
+
+	server := createMutualTLSServer(files)
+	defer server.Close()
+
+	_, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr: "127.0.0.1",
+		Port: port,
+		TLS: &driver.TLSOptions{
+			CAFile:   files.caFile,
+			CertFile: files.certFile,
+			KeyFile:  files.keyFile,
+		},
+	})
+
+	resp, err := rest.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", resp)
+
+	// without client certificate, server should reject the request
+	rest = driver.NewREST(&driver.RESTOptions{
+		Addr: "127.0.0.1",
+		Port: port,
+		TLS: &driver.TLSOptions{
+			CAFile: files.caFile,
+		},
+	})
+
+	_, err = rest.Ping()
+	assert.Error(t, err)
+}
+
+func createMutualTLSServer(files *certFiles) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"success","data":{"response":"pong"}}`))
+	}))
+
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{files.serverCert},
+		ClientCAs:    files.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	server.StartTLS()
+	return server
+}
+
+func TestRESTPingPinnedTLS(t *testing.T) {
+	files := createCertFiles(t)
+	defer os.RemoveAll(files.dir)
+
+	server := createMutualTLSServer(files)
+	defer server.Close()
+
+	pinned := driver.NewPinnedTLS()
+	err := pinned.Update(files.clientPEM, files.clientKey, files.serverPEM)
+	assert.NoError(t, err)
+
+	_, port := gethostport(server.URL)
+	rest := driver.NewREST(&driver.RESTOptions{
+		Addr: "127.0.0.1",
+		Port: port,
+		TLS: &driver.TLSOptions{
+			Pinned: pinned,
+		},
+	})
+
+	resp, err := rest.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", resp)
+
+	// plugin's certificate is not the pinned one
+	other := createCertFiles(t)
+	defer os.RemoveAll(other.dir)
+
+	err = pinned.Update(files.clientPEM, files.clientKey, other.serverPEM)
+	assert.NoError(t, err)
+
+	rest = driver.NewREST(&driver.RESTOptions{
+		Addr: "127.0.0.1",
+		Port: port,
+		TLS: &driver.TLSOptions{
+			Pinned: pinned,
+		},
+	})
+
+	_, err = rest.Ping()
+	assert.Error(t, err)
+}
+
+func TestPinnedTLSInvalidMaterials(t *testing.T) {
+	files := createCertFiles(t)
+	defer os.RemoveAll(files.dir)
+
+	pinned := driver.NewPinnedTLS()
+	err := pinned.Update(files.clientPEM, files.clientKey, []byte("invalid"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolTLSConfig))
+
+	err = pinned.Update(files.clientPEM, files.serverPEM, files.serverPEM)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolTLSConfig))
+}
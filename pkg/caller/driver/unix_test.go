@@ -0,0 +1,236 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/quadroops/goplugin/pkg/caller/driver"
+	pbPlugin "github.com/quadroops/goplugin/proto/plugin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type unixPluginServer struct {
+	pbPlugin.UnimplementedPluginServer
+}
+
+func (s *unixPluginServer) Ping(ctx context.Context, in *empty.Empty) (*pbPlugin.PingResponse, error) {
+	return &pbPlugin.PingResponse{Status: "success", Data: &pbPlugin.Data{Response: "pong"}}, nil
+}
+
+func (s *unixPluginServer) ExecStream(in *pbPlugin.ExecRequest, stream pbPlugin.Plugin_ExecStreamServer) error {
+	for _, b := range in.GetPayload() {
+		if err := stream.Send(&pbPlugin.ExecChunk{Data: []byte{b}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Session used to reply all messages in upper case, and send the total of
+// received messages after host's half-close
+func (s *unixPluginServer) Session(stream pbPlugin.Plugin_SessionServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	var total int
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return stream.Send(&pbPlugin.SessionMessage{Data: []byte(fmt.Sprintf("%s:%d", first.GetCommand(), total))})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		total++
+		err = stream.Send(&pbPlugin.SessionMessage{Data: bytes.ToUpper(msg.GetData())})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func createUnixListener(t *testing.T) (net.Listener, string) {
+	dir, err := ioutil.TempDir("", "goplugin")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+
+	return listener, socket
+}
+
+func TestRESTPingUnixSocket(t *testing.T) {
+	listener, socket := createUnixListener(t)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := json.Marshal(driver.JSONResponse{
+			Status: "success",
+			Data:   driver.JSONData{Response: "pong"},
+		})
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	})}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	rest := driver.NewREST(&driver.RESTOptions{Socket: socket})
+	resp, err := rest.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", resp)
+}
+
+func TestRESTUnixSocketCustomRoundTripper(t *testing.T) {
+	rest := driver.NewREST(&driver.RESTOptions{
+		Socket: "/tmp/unknown.sock",
+		Transport: &driver.RESTTransport{
+			RoundTripper: roundTripperFunc(http.DefaultTransport.RoundTrip),
+		},
+	})
+
+	_, err := rest.Ping()
+	assert.Error(t, err)
+}
+
+func TestGRPCPingUnixSocket(t *testing.T) {
+	listener, socket := createUnixListener(t)
+	server := grpc.NewServer()
+	pbPlugin.RegisterPluginServer(server, &unixPluginServer{})
+
+	go server.Serve(listener)
+	defer server.Stop()
+
+	opts := &driver.GrpcOptions{
+		Socket: socket,
+		Conns:  driver.NewGrpcConnManager(grpc.WithInsecure()),
+	}
+	defer opts.Close()
+
+	grpcCaller := driver.NewGRPC(opts)
+	resp, err := grpcCaller.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", resp)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestGRPCExecStreamUnixSocket(t *testing.T) {
+	listener, socket := createUnixListener(t)
+	server := grpc.NewServer()
+	pbPlugin.RegisterPluginServer(server, &unixPluginServer{})
+
+	go server.Serve(listener)
+	defer server.Stop()
+
+	opts := &driver.GrpcOptions{
+		Socket: socket,
+		Conns:  driver.NewGrpcConnManager(grpc.WithInsecure()),
+	}
+	defer opts.Close()
+
+	stream, err := driver.NewGRPC(opts).ExecStream(context.Background(), "test", []byte("abc"))
+	assert.NoError(t, err)
+
+	chunks, err := readStream(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, chunks)
+}
+
+func TestGRPCSessionUnixSocket(t *testing.T) {
+	listener, socket := createUnixListener(t)
+	server := grpc.NewServer()
+	pbPlugin.RegisterPluginServer(server, &unixPluginServer{})
+
+	go server.Serve(listener)
+	defer server.Stop()
+
+	opts := &driver.GrpcOptions{
+		Socket: socket,
+		Conns:  driver.NewGrpcConnManager(grpc.WithInsecure()),
+	}
+	defer opts.Close()
+
+	session, err := driver.NewGRPC(opts).Session(context.Background(), "upper")
+	assert.NoError(t, err)
+	defer session.Close()
+
+	for _, msg := range []string{"a", "b"} {
+		assert.NoError(t, session.Send([]byte(msg)))
+
+		resp, err := session.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, strings.ToUpper(msg), string(resp))
+	}
+
+	assert.NoError(t, session.CloseSend())
+
+	resp, err := session.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "upper:2", string(resp))
+
+	_, err = session.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRESTSocketChanged(t *testing.T) {
+	tcpServer := createServerPing(driver.JSONResponse{
+		Status: "success",
+		Data:   driver.JSONData{Response: "tcp"},
+	}, http.StatusOK)
+	defer tcpServer.Close()
+
+	listener, socket := createUnixListener(t)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(driver.JSONResponse{
+			Status: "success",
+			Data:   driver.JSONData{Response: "unix"},
+		})
+	})}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	host, port := gethostport(tcpServer.URL)
+	opts := &driver.RESTOptions{Addr: host, Port: port}
+	rest := driver.NewREST(opts)
+
+	resp, err := rest.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", resp)
+
+	client1, err := opts.Client()
+	assert.NoError(t, err)
+
+	// running callers should use restarted plugin's socket
+	opts.SetSocket(socket)
+	client2, err := opts.Client()
+	assert.NoError(t, err)
+	assert.NotSame(t, client1, client2)
+
+	resp, err = rest.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "unix", resp)
+}
@@ -2,7 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Caller is an autogenerated mock type for the Caller type
 type Caller struct {
@@ -32,6 +36,29 @@ func (_m *Caller) Exec(cmdName string, payload []byte) ([]byte, error) {
 	return r0, r1
 }
 
+// ExecContext provides a mock function with given fields: ctx, cmdName, payload
+func (_m *Caller) ExecContext(ctx context.Context, cmdName string, payload []byte) ([]byte, error) {
+	ret := _m.Called(ctx, cmdName, payload)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) []byte); ok {
+		r0 = rf(ctx, cmdName, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
+		r1 = rf(ctx, cmdName, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Ping provides a mock function with given fields:
 func (_m *Caller) Ping() (string, error) {
 	ret := _m.Called()
@@ -52,3 +79,24 @@ func (_m *Caller) Ping() (string, error) {
 
 	return r0, r1
 }
+
+// PingContext provides a mock function with given fields: ctx
+func (_m *Caller) PingContext(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
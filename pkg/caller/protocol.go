@@ -0,0 +1,81 @@
+package caller
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+var protocols = struct {
+	sync.RWMutex
+	factories map[string]ProtocolFactory
+}{
+	factories: make(map[string]ProtocolFactory),
+}
+
+// RegisterProtocol used to register a protocol's factory, registering an
+// existing protocol will replace its factory
+func RegisterProtocol(name string, factory ProtocolFactory) {
+	protocols.Lock()
+	defer protocols.Unlock()
+
+	protocols.factories[name] = factory
+}
+
+// IsProtocolRegistered used to check if given protocol has been registered
+func IsProtocolRegistered(name string) bool {
+	protocols.RLock()
+	defer protocols.RUnlock()
+
+	_, exist := protocols.factories[name]
+	return exist
+}
+
+// Protocols used to get all registered protocol's names
+func Protocols() []string {
+	protocols.RLock()
+	defer protocols.RUnlock()
+
+	var names []string
+	for name := range protocols.factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// NewProtocol used to create Caller instance using registered protocol's factory
+func NewProtocol(name string, opts interface{}) (Caller, error) {
+	protocols.RLock()
+	factory, exist := protocols.factories[name]
+	protocols.RUnlock()
+
+	if !exist {
+		return nil, fmt.Errorf("%w: %q", errs.ErrProtocolUnknown, name)
+	}
+
+	return factory(opts)
+}
+
+// ProtocolPort used to get port from protocol's options, options which doesn't
+// implement PortOptions will always use port 0
+func ProtocolPort(opts interface{}) int {
+	if p, ok := opts.(PortOptions); ok {
+		return p.GetPort()
+	}
+
+	return 0
+}
+
+// ProtocolAddress used to get plugin's address from protocol's options, options
+// which doesn't implement AddressOptions will always use an empty address
+func ProtocolAddress(opts interface{}) string {
+	if a, ok := opts.(AddressOptions); ok {
+		return a.GetAddress()
+	}
+
+	return ""
+}
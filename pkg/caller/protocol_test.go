@@ -0,0 +1,48 @@
+package caller_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/mocks"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type portOptions struct {
+	port int
+}
+
+func (o *portOptions) GetPort() int {
+	return o.port
+}
+
+func TestRegisterProtocol(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	caller.RegisterProtocol("test-protocol", func(opts interface{}) (caller.Caller, error) {
+		assert.Equal(t, "options", opts)
+		return mockCaller, nil
+	})
+
+	assert.True(t, caller.IsProtocolRegistered("test-protocol"))
+	assert.Contains(t, caller.Protocols(), "test-protocol")
+
+	c, err := caller.NewProtocol("test-protocol", "options")
+	assert.NoError(t, err)
+	assert.Equal(t, mockCaller, c)
+}
+
+func TestNewProtocolUnknown(t *testing.T) {
+	assert.False(t, caller.IsProtocolRegistered("unknown-protocol"))
+
+	_, err := caller.NewProtocol("unknown-protocol", nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolUnknown))
+}
+
+func TestProtocolPort(t *testing.T) {
+	assert.Equal(t, 8080, caller.ProtocolPort(&portOptions{8080}))
+	assert.Equal(t, 0, caller.ProtocolPort("options"))
+	assert.Equal(t, 0, caller.ProtocolPort(nil))
+}
@@ -0,0 +1,212 @@
+package caller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// DefaultMaxAttempts used when retry policy doesn't define max attempts
+	DefaultMaxAttempts = 5
+
+	// DefaultInitialInterval used when retry policy doesn't define initial interval
+	DefaultInitialInterval = 1 * time.Second
+
+	// DefaultMaxInterval used when retry policy doesn't define max interval
+	DefaultMaxInterval = 30 * time.Second
+
+	// DefaultMultiplier used when retry policy doesn't define multiplier
+	DefaultMultiplier = 2.0
+
+	// DefaultJitter used by DefaultRetryPolicy as randomization factor
+	DefaultJitter = 0.5
+)
+
+// RetryPolicy used to configure how a failed plugin's call should be retried.
+// Zero values will be replaced by their defaults, except Jitter, zero jitter
+// means the interval will not be randomized
+type RetryPolicy struct {
+	// MaxAttempts is total calls including the first one
+	MaxAttempts int
+
+	// InitialInterval used as waiting time before the first retry
+	InitialInterval time.Duration
+
+	// MaxInterval used as an upper bound of waiting time between retries
+	MaxInterval time.Duration
+
+	// Multiplier used to grow the interval after each retry
+	Multiplier float64
+
+	// Jitter used as randomization factor of each interval, between 0 and 1
+	Jitter float64
+
+	// Retryable used to classify an error, only retryable error will be retried
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy used to create default retry policy, given retryTimeout (in seconds)
+// will be used as initial interval
+func DefaultRetryPolicy(retryTimeout int) *RetryPolicy {
+	policy := &RetryPolicy{
+		MaxAttempts:     DefaultMaxAttempts,
+		InitialInterval: DefaultInitialInterval,
+		MaxInterval:     DefaultMaxInterval,
+		Multiplier:      DefaultMultiplier,
+		Jitter:          DefaultJitter,
+		Retryable:       IsRetryable,
+	}
+
+	if retryTimeout > 0 {
+		policy.InitialInterval = time.Duration(retryTimeout) * time.Second
+	}
+
+	return policy
+}
+
+// IsRetryable used as default retryable error's classifier.  All errors will be
+// retried except errors wrapping ignoredErrors, context's errors and an error
+// from an open circuit breaker.  errs.PluginError will be retried only if the
+// plugin flagged it as retryable
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pluginErr *errs.PluginError
+	if errors.As(err, &pluginErr) {
+		return pluginErr.Retryable
+	}
+
+	if errors.Is(err, errs.ErrPluginCircuitOpen) {
+		return false
+	}
+
+	// drivers wrap ignored errors with their causes
+	for ignored := range ignoredErrors {
+		if errors.Is(err, ignored) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *RetryPolicy) withDefaults() *RetryPolicy {
+	policy := *r
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = DefaultInitialInterval
+	}
+
+	if policy.MaxInterval < policy.InitialInterval {
+		policy.MaxInterval = DefaultMaxInterval
+		if policy.MaxInterval < policy.InitialInterval {
+			policy.MaxInterval = policy.InitialInterval
+		}
+	}
+
+	if policy.Multiplier < 1 {
+		policy.Multiplier = DefaultMultiplier
+	}
+
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		policy.Jitter = DefaultJitter
+	}
+
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+
+	return &policy
+}
+
+func (r *RetryPolicy) backoff() backoff.BackOff {
+	b := &backoff.ExponentialBackOff{
+		InitialInterval:     r.InitialInterval,
+		RandomizationFactor: r.Jitter,
+		Multiplier:          r.Multiplier,
+		MaxInterval:         r.MaxInterval,
+		Stop:                backoff.Stop,
+		Clock:               backoff.SystemClock,
+	}
+
+	b.Reset()
+	return b
+}
+
+// RetryError returned when retrying a plugin's call stopped before success, its Err is
+// errs.ErrPluginRetryExhausted or context's error.  All attempt's causes will be kept,
+// and it unwraps into the last cause so the cause still can be checked using errors.Is
+// and errors.As
+type RetryError struct {
+	Err    error
+	Causes []error
+}
+
+// Error implement error interface
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, multierror.Append(nil, e.Causes...))
+}
+
+// Is used to match RetryError's Err, its causes matched through Unwrap
+func (e *RetryError) Is(target error) bool {
+	return errors.Is(e.Err, target)
+}
+
+// Unwrap used to unwrap RetryError as its last cause
+func (e *RetryError) Unwrap() error {
+	if len(e.Causes) < 1 {
+		return nil
+	}
+
+	return e.Causes[len(e.Causes)-1]
+}
+
+// retry used to run given operation until it's success, return an unretryable error
+// or reach policy's max attempts.  When all attempts have been used, it will return
+// RetryError of errs.ErrPluginRetryExhausted listing all attempt's causes
+func (r *RetryPolicy) retry(ctx context.Context, operation func() error) error {
+	var causes []error
+	b := r.backoff()
+
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+
+		if !r.Retryable(err) {
+			return err
+		}
+
+		causes = append(causes, fmt.Errorf("attempt %d: %w", attempt, err))
+		if attempt >= r.MaxAttempts {
+			return &RetryError{Err: errs.ErrPluginRetryExhausted, Causes: causes}
+		}
+
+		if ctx.Err() != nil {
+			return &RetryError{Err: ctx.Err(), Causes: causes}
+		}
+
+		log.Printf("Retrying process error: %v", err)
+		timer := time.NewTimer(b.NextBackOff())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &RetryError{Err: ctx.Err(), Causes: causes}
+		case <-timer.C:
+		}
+	}
+}
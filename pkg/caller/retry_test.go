@@ -0,0 +1,125 @@
+package caller_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/mocks"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/host"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func makeRetryPolicy(maxAttempts int) *caller.RetryPolicy {
+	return &caller.RetryPolicy{
+		MaxAttempts:     maxAttempts,
+		InitialInterval: 10 * time.Millisecond,
+		MaxInterval:     20 * time.Millisecond,
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("PingContext", mock.Anything).Return("", errs.ErrProtocolRESTRequest)
+
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, mockCaller, makeRetryPolicy(3))
+	_, err := plugin.Ping()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginRetryExhausted))
+	assert.Equal(t, 3, strings.Count(err.Error(), errs.ErrProtocolRESTRequest.Error()))
+	mockCaller.AssertNumberOfCalls(t, "PingContext", 3)
+}
+
+func TestRetrySuccessAfterFailure(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return(nil, errs.ErrProtocolRESTRequest)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return([]byte("world"), nil)
+
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, mockCaller, makeRetryPolicy(3))
+	resp, err := plugin.Exec("test.action", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("world"), resp)
+	mockCaller.AssertNumberOfCalls(t, "ExecContext", 2)
+}
+
+func TestRetryCustomClassifier(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("PingContext", mock.Anything).Return("", errs.ErrProtocolRESTRequest)
+
+	policy := makeRetryPolicy(3)
+	policy.Retryable = func(err error) bool {
+		return !errors.Is(err, errs.ErrProtocolRESTRequest)
+	}
+
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, mockCaller, policy)
+	_, err := plugin.Ping()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolRESTRequest))
+	mockCaller.AssertNumberOfCalls(t, "PingContext", 1)
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	policy := caller.DefaultRetryPolicy(2)
+	assert.Equal(t, caller.DefaultMaxAttempts, policy.MaxAttempts)
+	assert.Equal(t, 2*time.Second, policy.InitialInterval)
+	assert.True(t, policy.Retryable(errs.ErrProtocolRESTRequest))
+	assert.False(t, policy.Retryable(errs.ErrPluginExec))
+}
+
+func TestRetryWrappedIgnoredErrors(t *testing.T) {
+	assert.False(t, caller.IsRetryable(fmt.Errorf("%w: %q", errs.ErrPluginExec, errors.New("rpc error"))))
+	assert.False(t, caller.IsRetryable(fmt.Errorf("%w: %q", errs.ErrPluginPing, errors.New("rpc error"))))
+	assert.True(t, caller.IsRetryable(fmt.Errorf("%w: %q", errs.ErrProtocolRESTRequest, errors.New("refused"))))
+
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Return(nil, fmt.Errorf("%w: %q", errs.ErrPluginExec, errors.New("rpc error")))
+
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, mockCaller, makeRetryPolicy(3))
+	_, err := plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+	mockCaller.AssertNumberOfCalls(t, "ExecContext", 1)
+}
+
+func TestRetryPluginError(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return(nil, &errs.PluginError{Code: "BUSY", Retryable: true})
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Once().Return(nil, &errs.PluginError{Code: "INVALID", Message: "invalid payload"})
+
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, mockCaller, makeRetryPolicy(3))
+	_, err := plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+
+	var pluginErr *errs.PluginError
+	assert.True(t, errors.As(err, &pluginErr))
+	assert.Equal(t, "INVALID", pluginErr.Code)
+	assert.Equal(t, "invalid payload", pluginErr.Message)
+	mockCaller.AssertNumberOfCalls(t, "ExecContext", 2)
+}
+
+func TestRetryExhaustedUnwrapCause(t *testing.T) {
+	mockCaller := new(mocks.Caller)
+	mockCaller.On("ExecContext", mock.Anything, "test.action", []byte("hello")).Return(nil, &errs.PluginError{Code: "BUSY", Message: "busy\nretry later", Retryable: true})
+
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, mockCaller, makeRetryPolicy(2))
+	_, err := plugin.Exec("test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginRetryExhausted))
+	assert.True(t, errors.Is(err, errs.ErrPluginExec))
+	assert.Contains(t, err.Error(), "busy\nretry later")
+
+	var pluginErr *errs.PluginError
+	assert.True(t, errors.As(err, &pluginErr))
+	assert.Equal(t, "BUSY", pluginErr.Code)
+
+	var retryErr *caller.RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Len(t, retryErr.Causes, 2)
+}
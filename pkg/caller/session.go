@@ -0,0 +1,35 @@
+package caller
+
+import (
+	"context"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// Session used to open a bidirectional session for given command, only opening the
+// session will be guarded by circuit breaker and retried using retry policy.  The
+// session will be ended when given context cancelled
+func (p *Plugin) Session(ctx context.Context, cmdName string) (Session, error) {
+	opener, ok := p.transporter.(SessionCaller)
+	if !ok {
+		return nil, errs.ErrProtocolSessionUnsupported
+	}
+
+	var session Session
+	err := p.retryPolicy.retry(ctx, func() error {
+		if err := p.guard(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		session, err = opener.Session(ctx, cmdName)
+		p.record(err)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
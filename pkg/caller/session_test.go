@@ -0,0 +1,70 @@
+package caller_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/mocks"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/host"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoSession struct {
+	messages chan []byte
+}
+
+func (s *echoSession) Send(data []byte) error {
+	s.messages <- data
+	return nil
+}
+
+func (s *echoSession) Recv() ([]byte, error) {
+	data, ok := <-s.messages
+	if !ok {
+		return nil, io.EOF
+	}
+
+	return data, nil
+}
+
+func (s *echoSession) CloseSend() error {
+	close(s.messages)
+	return nil
+}
+
+func (s *echoSession) Close() error {
+	return nil
+}
+
+type sessionCaller struct {
+	mocks.Caller
+}
+
+func (c *sessionCaller) Session(ctx context.Context, cmdName string) (caller.Session, error) {
+	return &echoSession{messages: make(chan []byte, 1)}, nil
+}
+
+func TestSessionSuccess(t *testing.T) {
+	plugin := caller.New(&host.Registry{}, &sessionCaller{}, 3)
+	session, err := plugin.Session(context.Background(), "echo")
+	assert.NoError(t, err)
+
+	assert.NoError(t, session.Send([]byte("hello")))
+	resp, err := session.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(resp))
+
+	assert.NoError(t, session.CloseSend())
+	_, err = session.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSessionUnsupported(t *testing.T) {
+	plugin := caller.New(&host.Registry{}, new(mocks.Caller), 3)
+	_, err := plugin.Session(context.Background(), "echo")
+	assert.True(t, errors.Is(err, errs.ErrProtocolSessionUnsupported))
+}
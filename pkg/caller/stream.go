@@ -0,0 +1,35 @@
+package caller
+
+import (
+	"context"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// ExecStream used to send exec request and read plugin's response as a stream, only
+// opening the stream will be guarded by circuit breaker and retried using retry policy.
+// Once the stream opened, any error will be returned by stream's Recv
+func (p *Plugin) ExecStream(ctx context.Context, cmdName string, payload []byte) (Stream, error) {
+	streamer, ok := p.transporter.(StreamCaller)
+	if !ok {
+		return nil, errs.ErrProtocolStreamUnsupported
+	}
+
+	var stream Stream
+	err := p.retryPolicy.retry(ctx, func() error {
+		if err := p.guard(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		stream, err = streamer.ExecStream(ctx, cmdName, payload)
+		p.record(err)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stream, nil
+}
@@ -0,0 +1,82 @@
+package caller_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/caller/mocks"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/host"
+	"github.com/stretchr/testify/assert"
+)
+
+type sliceStream struct {
+	chunks [][]byte
+}
+
+func (s *sliceStream) Recv() ([]byte, error) {
+	if len(s.chunks) < 1 {
+		return nil, io.EOF
+	}
+
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *sliceStream) Close() error {
+	return nil
+}
+
+type streamCaller struct {
+	mocks.Caller
+	errors []error
+	calls  int
+}
+
+func (c *streamCaller) ExecStream(ctx context.Context, cmdName string, payload []byte) (caller.Stream, error) {
+	c.calls++
+	if len(c.errors) > 0 {
+		err := c.errors[0]
+		c.errors = c.errors[1:]
+		return nil, err
+	}
+
+	return &sliceStream{chunks: [][]byte{[]byte(cmdName), payload}}, nil
+}
+
+func TestExecStreamSuccess(t *testing.T) {
+	transporter := &streamCaller{errors: []error{errs.ErrProtocolRESTRequest}}
+	plugin := caller.NewWithRetryPolicy(&host.Registry{}, transporter, &caller.RetryPolicy{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+	})
+
+	stream, err := plugin.ExecStream(context.Background(), "test.action", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, transporter.calls)
+
+	var chunks []string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		assert.NoError(t, err)
+		chunks = append(chunks, string(chunk))
+	}
+
+	assert.Equal(t, []string{"test.action", "hello"}, chunks)
+}
+
+func TestExecStreamUnsupported(t *testing.T) {
+	plugin := caller.New(&host.Registry{}, new(mocks.Caller), 3)
+	_, err := plugin.ExecStream(context.Background(), "test.action", []byte("hello"))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolStreamUnsupported))
+}
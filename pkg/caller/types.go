@@ -1,25 +1,91 @@
 package caller
 
-import "github.com/quadroops/goplugin/pkg/host"
+import (
+	"context"
+
+	"github.com/quadroops/goplugin/pkg/host"
+)
 
 var (
 	// AllowedProtocols used as main supported protocols
-	AllowedProtocols = []string{"rest", "grpc"}
+	//
+	// Deprecated: protocols are registered using RegisterProtocol, use Protocols
+	// or IsProtocolRegistered instead.  It only lists builtin protocols
+	AllowedProtocols = []string{"rest", "grpc", "stdio"}
 )
 
 // Builder used as a simple function to create Caller instance
 type Builder func(commType string, port int) Caller
 
+// ProtocolFactory used to create Caller instance from plugin's protocol options,
+// the options value depends on the protocol, such as *driver.RESTOptions for rest
+type ProtocolFactory func(opts interface{}) (Caller, error)
+
+// PortOptions should be implemented by protocol's options which listening on
+// a tcp port, it's used to resolve the port given to plugin's process
+type PortOptions interface {
+	GetPort() int
+}
+
+// AddressOptions should be implemented by protocol's options which know plugin's
+// address, formatted as host:port or unix:// prefixed socket's path
+type AddressOptions interface {
+	GetAddress() string
+}
+
 // Caller used as main communication interface
 type Caller interface {
 	Ping() (string, error)
 	Exec(cmdName string, payload []byte) ([]byte, error)
+
+	// PingContext and ExecContext should stop their process as soon as
+	// given context cancelled or its deadline exceeded
+	PingContext(ctx context.Context) (string, error)
+	ExecContext(ctx context.Context, cmdName string, payload []byte) ([]byte, error)
+}
+
+// CodecNegotiator should be implemented by a Caller which knows plugin's supported
+// codecs, usually advertised on plugin's ping response.  Empty codecs means the
+// plugin doesn't advertise any codecs
+type CodecNegotiator interface {
+	Codecs(ctx context.Context) ([]string, error)
+}
+
+// Stream used to read plugin's response chunk by chunk, Recv will return io.EOF
+// when plugin has finished sending its response.  Close should be called to release
+// stream's resources if the response is not read until the end
+type Stream interface {
+	Recv() ([]byte, error)
+	Close() error
+}
+
+// StreamCaller should be implemented by a Caller which support streaming response
+type StreamCaller interface {
+	ExecStream(ctx context.Context, cmdName string, payload []byte) (Stream, error)
+}
+
+// Session used as a long-lived bidirectional session between host and plugin.  Send
+// and Recv may be called from different goroutines, but each of them should not be
+// called concurrently.  Send will block when plugin doesn't consume its messages fast
+// enough.  CloseSend used to tell plugin that host will not send any messages anymore,
+// while Recv still can be used until it returns io.EOF.  Close used to end the session
+type Session interface {
+	Send(data []byte) error
+	Recv() ([]byte, error)
+	CloseSend() error
+	Close() error
+}
+
+// SessionCaller should be implemented by a Caller which support bidirectional sessions
+type SessionCaller interface {
+	Session(ctx context.Context, cmdName string) (Session, error)
 }
 
 // Plugin is single plugin instance used to store
 // meta information and also caller activity
 type Plugin struct {
-	Meta         *host.Registry
-	transporter  Caller
-	retryTimeout int
+	Meta        *host.Registry
+	transporter Caller
+	retryPolicy *RetryPolicy
+	breaker     *Breaker
 }
@@ -0,0 +1,231 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// HeaderContentType used to tell rest plugins payload's content type
+	HeaderContentType = "X-Goplugin-Content-Type"
+
+	// MetadataContentType used to tell grpc plugins payload's content type
+	MetadataContentType = "goplugin-content-type"
+
+	// HeaderCodecs used by rest plugins to advertise their supported codecs on ping's response
+	HeaderCodecs = "X-Goplugin-Codecs"
+
+	// MetadataCodecs used by grpc plugins to advertise their supported codecs on ping's header
+	MetadataCodecs = "goplugin-codecs"
+)
+
+// Codec used to encode and decode exec's payloads
+type Codec interface {
+	Name() string
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON used as default codec, encoding using encoding/json
+	JSON Codec = jsonCodec{}
+
+	// Msgpack used to encode payloads using msgpack
+	Msgpack Codec = msgpackCodec{}
+
+	// Protobuf used to encode payloads using protobuf, values must implement proto.Message
+	Protobuf Codec = protobufCodec{}
+
+	registry = struct {
+		sync.RWMutex
+		codecs map[string]Codec
+	}{codecs: make(map[string]Codec)}
+)
+
+func init() {
+	Register(JSON)
+	Register(Msgpack)
+	Register(Protobuf)
+}
+
+// Register used to register a codec by its name, registering an existing name
+// will replace its codec
+func Register(c Codec) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.codecs[c.Name()] = c
+}
+
+// Get used to get registered codec by its name, JSON will be used for empty name
+func Get(name string) (Codec, error) {
+	if name == "" {
+		return JSON, nil
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	c, exist := registry.codecs[name]
+	if !exist {
+		return nil, fmt.Errorf("%w: %s", errs.ErrCodecUnknown, name)
+	}
+
+	return c, nil
+}
+
+// Negotiate used to choose a codec supported by the plugin.  Configured codec will be
+// used if the plugin supports it, otherwise the first registered codec advertised by
+// the plugin will be used.  Plugins which doesn't advertise any codecs will use
+// configured codec
+func Negotiate(configured string, supported []string) (Codec, error) {
+	if len(supported) == 0 {
+		return Get(configured)
+	}
+
+	if configured != "" {
+		for _, name := range supported {
+			if name == configured {
+				return Get(configured)
+			}
+		}
+
+		return nil, fmt.Errorf("%w: %s, plugin supports %s", errs.ErrCodecUnsupported, configured, strings.Join(supported, ","))
+	}
+
+	for _, name := range supported {
+		if c, err := Get(name); err == nil {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: plugin supports %s", errs.ErrCodecUnsupported, strings.Join(supported, ","))
+}
+
+// Parse used to parse comma separated codec names advertised by plugins
+func Parse(values ...string) []string {
+	var names []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+type contentTypeKey struct{}
+
+// WithContentType used to put payload's content type to the context, it will be
+// sent to the plugin by callers
+func WithContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contentTypeKey{}, contentType)
+}
+
+// ContentType used to get payload's content type from the context
+func ContentType(ctx context.Context) string {
+	contentType, _ := ctx.Value(contentTypeKey{}).(string)
+	return contentType
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCodecMarshal, err)
+	}
+
+	return b, nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrCodecUnmarshal, err)
+	}
+
+	return nil
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCodecMarshal, err)
+	}
+
+	return b, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := msgpack.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrCodecUnmarshal, err)
+	}
+
+	return nil
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string {
+	return "protobuf"
+}
+
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not a proto.Message", errs.ErrCodecMarshal, v)
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrCodecMarshal, err)
+	}
+
+	return b, nil
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a proto.Message", errs.ErrCodecUnmarshal, v)
+	}
+
+	if err := proto.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrCodecUnmarshal, err)
+	}
+
+	return nil
+}
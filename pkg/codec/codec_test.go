@@ -0,0 +1,105 @@
+package codec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/codec"
+	"github.com/quadroops/goplugin/pkg/errs"
+	pbPlugin "github.com/quadroops/goplugin/proto/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	Name  string `json:"name" msgpack:"name"`
+	Count int    `json:"count" msgpack:"count"`
+}
+
+func TestCodecsRoundtrip(t *testing.T) {
+	for _, name := range []string{"json", "msgpack"} {
+		c, err := codec.Get(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, c.Name())
+
+		b, err := c.Marshal(payload{Name: "test", Count: 1})
+		assert.NoError(t, err)
+
+		var out payload
+		assert.NoError(t, c.Unmarshal(b, &out))
+		assert.Equal(t, payload{Name: "test", Count: 1}, out)
+	}
+}
+
+func TestProtobuf(t *testing.T) {
+	b, err := codec.Protobuf.Marshal(&pbPlugin.ExecRequest{Command: "test", Payload: []byte("hello")})
+	assert.NoError(t, err)
+
+	var out pbPlugin.ExecRequest
+	assert.NoError(t, codec.Protobuf.Unmarshal(b, &out))
+	assert.Equal(t, "test", out.GetCommand())
+	assert.Equal(t, []byte("hello"), out.GetPayload())
+
+	_, err = codec.Protobuf.Marshal(payload{})
+	assert.True(t, errors.Is(err, errs.ErrCodecMarshal))
+
+	err = codec.Protobuf.Unmarshal(b, &payload{})
+	assert.True(t, errors.Is(err, errs.ErrCodecUnmarshal))
+}
+
+func TestGet(t *testing.T) {
+	c, err := codec.Get("")
+	assert.NoError(t, err)
+	assert.Equal(t, codec.JSON, c)
+
+	_, err = codec.Get("unknown")
+	assert.True(t, errors.Is(err, errs.ErrCodecUnknown))
+}
+
+func TestUnmarshalError(t *testing.T) {
+	var out payload
+	err := codec.JSON.Unmarshal([]byte("invalid"), &out)
+	assert.True(t, errors.Is(err, errs.ErrCodecUnmarshal))
+}
+
+func TestContentType(t *testing.T) {
+	assert.Empty(t, codec.ContentType(context.Background()))
+
+	ctx := codec.WithContentType(context.Background(), codec.Msgpack.ContentType())
+	assert.Equal(t, "application/msgpack", codec.ContentType(ctx))
+}
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configured string
+		supported  []string
+		expected   string
+		err        error
+	}{
+		{"no advertised codecs", "msgpack", nil, "msgpack", nil},
+		{"default without advertised codecs", "", nil, "json", nil},
+		{"configured supported", "msgpack", []string{"json", "msgpack"}, "msgpack", nil},
+		{"configured unsupported", "msgpack", []string{"json"}, "", errs.ErrCodecUnsupported},
+		{"plugin's preference", "", []string{"unknown", "msgpack", "json"}, "msgpack", nil},
+		{"nothing registered", "", []string{"unknown"}, "", errs.ErrCodecUnsupported},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := codec.Negotiate(tc.configured, tc.supported)
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, c.Name())
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	assert.Equal(t, []string{"msgpack", "json", "protobuf"}, codec.Parse("msgpack, json", "", "protobuf"))
+	assert.Nil(t, codec.Parse(""))
+}
@@ -117,4 +117,35 @@ func TestParseInvalidToml(t *testing.T) {
 	parser := driver.NewTomlParser()
 	_, err := parser.Parse([]byte(tomlInvalidContent))
 	assert.Error(t, err)
-}
\ No newline at end of file
+}
+func TestParsePluginTLS(t *testing.T) {
+	content := `
+	[plugins]
+
+		[plugins.name_1]
+		exec = "/path/to/exec"
+		comm_type = "grpc"
+
+			[plugins.name_1.tls]
+			ca_file = "/path/to/ca.pem"
+			cert_file = "/path/to/client.pem"
+			key_file = "/path/to/client.key"
+			server_name = "plugin.local"
+
+		[plugins.name_2]
+		exec = "/path/to/exec"
+		comm_type = "rest"
+	`
+
+	parser := driver.NewTomlParser()
+	conf, err := parser.Parse([]byte(content))
+	assert.NoError(t, err)
+
+	tls := conf.Plugins["name_1"].TLS
+	assert.NotNil(t, tls)
+	assert.Equal(t, "/path/to/ca.pem", tls.CAFile)
+	assert.Equal(t, "/path/to/client.pem", tls.CertFile)
+	assert.Equal(t, "/path/to/client.key", tls.KeyFile)
+	assert.Equal(t, "plugin.local", tls.ServerName)
+	assert.Nil(t, conf.Plugins["name_2"].TLS)
+}
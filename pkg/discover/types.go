@@ -36,20 +36,73 @@ type PluginSettings struct {
 	Debug bool `toml:"debug"`
 }
 
+// PluginTLS used to save plugin's [plugins.<name>.tls] informations
+type PluginTLS struct {
+	CAFile     string `toml:"ca_file"`
+	CertFile   string `toml:"cert_file"`
+	KeyFile    string `toml:"key_file"`
+	ServerName string `toml:"server_name"`
+}
+
+// PluginReadiness used to save plugin's [plugins.<name>.readiness] informations.
+// Timeout and Interval defined in milliseconds, plugin's exec time will be used
+// as timeout if it's not defined
+type PluginReadiness struct {
+	Strategy string `toml:"strategy"`
+	Timeout  int    `toml:"timeout"`
+	Interval int    `toml:"interval"`
+}
+
+// PluginLimits used to save plugin's [plugins.<name>.limits] informations.  Memory
+// defined in bytes and CPUTime in seconds, CoreSize 0 used to disable core dumps.
+// CPU used as cgroup's cpu.max quota in number of CPUs
+type PluginLimits struct {
+	Memory    int64   `toml:"memory"`
+	CPUTime   int64   `toml:"cpu_time"`
+	OpenFiles int64   `toml:"open_files"`
+	Processes int64   `toml:"processes"`
+	CoreSize  *int64  `toml:"core_size"`
+	CPU       float64 `toml:"cpu"`
+}
+
 // PluginInfo used to save all plugin's basic informations
 type PluginInfo struct {
-	Author       string   `toml:"author"`
-	MD5          string   `toml:"md5"`
-	Exec         string   `toml:"exec"`
-	ExecArgs     []string `toml:"exec_args"`
-	ExecFile     string   `toml:"exec_file"`
-	ExecTime     int      `toml:"exec_time"`
-	ProtocolType string   `toml:"comm_type"`
+	Author       string           `toml:"author"`
+	MD5          string           `toml:"md5"`
+	Exec         string           `toml:"exec"`
+	ExecArgs     []string         `toml:"exec_args"`
+	ExecFile     string           `toml:"exec_file"`
+	ExecTime     int              `toml:"exec_time"`
+	ProtocolType string           `toml:"comm_type"`
+	Codec        string           `toml:"codec"`
+	TLS          *PluginTLS       `toml:"tls"`
+	Readiness    *PluginReadiness `toml:"readiness"`
+	Limits       *PluginLimits    `toml:"limits"`
+
+	// plugin's process environment, host's environment will be inherited
+	// unless ClearEnv is true.  Secrets used as secret references resolved
+	// by host's resolvers, such as "file:/run/secrets/token"
+	Env            map[string]string `toml:"env"`
+	EnvPassthrough []string          `toml:"env_passthrough"`
+	EnvFromFile    map[string]string `toml:"env_from_file"`
+	Secrets        map[string]string `toml:"secrets"`
+	ClearEnv       bool              `toml:"clear_env"`
+	Workdir        string            `toml:"workdir"`
+}
+
+// PluginEvents used to save host's [hosts.<name>.events] permissions, a map of
+// plugin and its allowed topic patterns
+type PluginEvents struct {
+	Publish   map[string][]string `toml:"publish"`
+	Subscribe map[string][]string `toml:"subscribe"`
 }
 
-// PluginHost used to save all registered service's plugins
+// PluginHost used to save all registered service's plugins.  Calls used as
+// allow-list of plugin to plugin calls, a map of caller and its callee plugins
 type PluginHost struct {
-	Plugins []string `toml:"plugins"`
+	Plugins []string            `toml:"plugins"`
+	Calls   map[string][]string `toml:"calls"`
+	Events  PluginEvents        `toml:"events"`
 }
 
 // PluginConfig used to store all plugin configuration values
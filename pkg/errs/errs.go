@@ -37,9 +37,39 @@ var (
 	// ErrPluginCannotBeKilled used when failing to kill the plugin
 	ErrPluginCannotBeKilled = errors.New("Plugin cannot be killed")
 
+	// ErrPluginStop used when failed to send stop signal to plugin's process
+	ErrPluginStop = errors.New("Plugin cannot stop")
+
+	// ErrPluginStopTimeout used when plugin has been killed after its grace period
+	ErrPluginStopTimeout = errors.New("Plugin doesn't stop within grace period")
+
+	// ErrPluginExited used when plugin's process exited without asked to stop by the host
+	ErrPluginExited = errors.New("Plugin's process exited")
+
+	// ErrPluginEnv used when failed to build plugin's process environment
+	ErrPluginEnv = errors.New("Invalid plugin's environment")
+
+	// ErrPluginSecret used when failed to resolve plugin's secret
+	ErrPluginSecret = errors.New("Cannot resolve plugin's secret")
+
+	// ErrPluginLimits used when failed to apply plugin's resource limits
+	ErrPluginLimits = errors.New("Cannot apply plugin's resource limits")
+
 	// ErrPluginStarted used when host try to run a plugin twice
 	ErrPluginStarted = errors.New("Plugin has been started")
 
+	// ErrPluginCredentials used when failed to generate plugin's ephemeral credentials
+	ErrPluginCredentials = errors.New("Cannot generate plugin's credentials")
+
+	// ErrPluginHandshake used when plugin doesn't send a valid launch handshake
+	ErrPluginHandshake = errors.New("Plugin handshake failed")
+
+	// ErrPluginAddress used when plugin started on port 0 doesn't report its address
+	ErrPluginAddress = errors.New("Plugin address not reported")
+
+	// ErrProtocolStdioClosed used when plugin's stdio connection has been closed
+	ErrProtocolStdioClosed = errors.New("Stdio connection closed")
+
 	// ErrEmptyProcesses used when there are no processes attached
 	ErrEmptyProcesses = errors.New("No processes available")
 
@@ -52,15 +82,103 @@ var (
 	// ErrPluginExec used when found any errors when calling an exec command to plugin
 	ErrPluginExec = errors.New("Plugin cannot exec")
 
+	// ErrPluginRetryExhausted used when all retry attempts to call a plugin have been used
+	ErrPluginRetryExhausted = errors.New("Plugin retry attempts exhausted")
+
+	// ErrPluginCircuitOpen used when plugin's circuit breaker is open and request has been rejected
+	ErrPluginCircuitOpen = errors.New("Plugin circuit breaker is open")
+
 	// ErrProtocolUnknown used when plugin define unsuppported protocol
 	ErrProtocolUnknown = errors.New("Illegal protocol")
 
+	// ErrProtocolOptions used when protocol's options doesn't match the protocol
+	ErrProtocolOptions = errors.New("Invalid protocol options")
+
+	// ErrProtocolStreamUnsupported used when plugin's protocol doesn't support streaming response
+	ErrProtocolStreamUnsupported = errors.New("Protocol doesn't support streaming")
+
+	// ErrProtocolSessionUnsupported used when plugin's protocol doesn't support bidirectional sessions
+	ErrProtocolSessionUnsupported = errors.New("Protocol doesn't support sessions")
+
 	// ErrProtocolRESTRequest used when rest plugin trigger an error when do request action
 	ErrProtocolRESTRequest = errors.New("Error request rest connection")
 
 	// ErrProtocolGRPCConnection used for an error grpc connection
 	ErrProtocolGRPCConnection = errors.New("Error grpc connection")
 
+	// ErrProtocolTLSConfig used when failed to build tls configurations for plugin's connection
+	ErrProtocolTLSConfig = errors.New("Invalid tls configurations")
+
+	// ErrCallbackServer used when failed to start host's callback server
+	ErrCallbackServer = errors.New("Cannot start callback server")
+
+	// ErrCallbackUnavailable used when plugin started without callback server
+	ErrCallbackUnavailable = errors.New("Callback server is not available")
+
+	// ErrCallbackUnauthorized used when plugin's callback credential is invalid
+	ErrCallbackUnauthorized = errors.New("Unauthorized callback request")
+
+	// ErrCallbackUnknownService used when plugin call an unregistered host's service
+	ErrCallbackUnknownService = errors.New("Unknown callback service")
+
+	// ErrCallbackRequest used when failed to build or send callback request
+	ErrCallbackRequest = errors.New("Error callback request")
+
+	// ErrCallbackFailed used when host's service returned an error
+	ErrCallbackFailed = errors.New("Callback service failed")
+
+	// ErrCallbackKeyNotFound used when builtin kv service cannot find given key
+	ErrCallbackKeyNotFound = errors.New("Callback key not found")
+
+	// ErrPluginCallDenied used when a plugin not allowed to call other plugin
+	ErrPluginCallDenied = errors.New("Plugin call denied")
+
+	// ErrPluginCallLoop used when a plugin call will create a loop on its call chain
+	ErrPluginCallLoop = errors.New("Plugin call loop detected")
+
+	// ErrCallbackHost used when plugin's callback request targets a host other than
+	// the host bound to its callback server
+	ErrCallbackHost = errors.New("Callback host mismatch")
+
+	// ErrEventBusUnavailable used when host doesn't have an event bus
+	ErrEventBusUnavailable = errors.New("Event bus is not available")
+
+	// ErrEventBusClosed used when publish or subscribe to a closed event bus
+	ErrEventBusClosed = errors.New("Event bus closed")
+
+	// ErrEventTopic used when given topic is not a valid pattern
+	ErrEventTopic = errors.New("Invalid event topic")
+
+	// ErrEventBufferFull used when subscriber's buffer is full and the event cannot be delivered
+	ErrEventBufferFull = errors.New("Event subscriber's buffer is full")
+
+	// ErrEventUndelivered used when an event still cannot be delivered after all attempts
+	ErrEventUndelivered = errors.New("Event undelivered")
+
+	// ErrEventPayload used when failed to decode pushed event
+	ErrEventPayload = errors.New("Invalid event payload")
+
+	// ErrEventPublishDenied used when a plugin not allowed to publish on a topic
+	ErrEventPublishDenied = errors.New("Event publish denied")
+
+	// ErrEventSubscribeDenied used when a plugin not allowed to subscribe a topic
+	ErrEventSubscribeDenied = errors.New("Event subscribe denied")
+
+	// ErrCodecUnknown used when plugin using unregistered codec
+	ErrCodecUnknown = errors.New("Unknown codec")
+
+	// ErrCodecUnsupported used when plugin doesn't support configured codec or any registered codecs
+	ErrCodecUnsupported = errors.New("Codec not supported by plugin")
+
+	// ErrCodecMarshal used when failed to encode exec's payload
+	ErrCodecMarshal = errors.New("Cannot encode payload")
+
+	// ErrCodecUnmarshal used when failed to decode exec's response
+	ErrCodecUnmarshal = errors.New("Cannot decode response")
+
+	// ErrPluginNotReady used when plugin's readiness probe failed
+	ErrPluginNotReady = errors.New("Plugin is not ready")
+
 	// ErrSupervisorNoHandlers used when there are no error handlers registered for supervisor
 	ErrSupervisorNoHandlers = errors.New("No supervisor error handlers defined")
 )
@@ -0,0 +1,27 @@
+package errs
+
+import "fmt"
+
+// PluginError used as structured error returned by plugins.  Retryable used by caller's
+// retry policy to decide if the request should be retried.  PluginError wraps
+// ErrPluginExec, so it still can be checked using errors.Is
+type PluginError struct {
+	Code      string
+	Message   string
+	Details   map[string]string
+	Retryable bool
+}
+
+// Error implement error interface
+func (e *PluginError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s: %s", ErrPluginExec, e.Message)
+	}
+
+	return fmt.Sprintf("%s: [%s] %s", ErrPluginExec, e.Code, e.Message)
+}
+
+// Unwrap used to unwrap PluginError as ErrPluginExec
+func (e *PluginError) Unwrap() error {
+	return ErrPluginExec
+}
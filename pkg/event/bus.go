@@ -0,0 +1,336 @@
+package event
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// Bus used as in-host publish/subscribe between the host and its plugins.  Each
+// subscriber has its own buffer and delivery goroutine, events will be delivered
+// at least once in publishing order unless their attempts exhausted
+type Bus struct {
+	mutex       sync.RWMutex
+	opts        Options
+	nextID      uint64
+	closed      bool
+	subscribers map[string]*subscriber
+}
+
+type subscriber struct {
+	name    string
+	topics  map[string]bool
+	deliver Deliverer
+	queue   chan Event
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// tail closed once the last publisher's event queued or dropped, publishers
+	// wait their previous publisher so events queued in the same order as their ids
+	tail chan struct{}
+}
+
+// NewBus used to create new instance of Bus, default options will be used for
+// undefined values
+func NewBus(opts *Options) *Bus {
+	o := Options{
+		Buffer:        DefaultBuffer,
+		RetryInterval: DefaultRetryInterval,
+		MaxAttempts:   DefaultMaxAttempts,
+		DeadLetter:    logDeadLetter,
+	}
+
+	if opts != nil {
+		if opts.Buffer > 0 {
+			o.Buffer = opts.Buffer
+		}
+
+		if opts.RetryInterval > 0 {
+			o.RetryInterval = opts.RetryInterval
+		}
+
+		if opts.MaxAttempts > 0 {
+			o.MaxAttempts = opts.MaxAttempts
+		}
+
+		if opts.DeadLetter != nil {
+			o.DeadLetter = opts.DeadLetter
+		}
+	}
+
+	return &Bus{
+		opts:        o,
+		subscribers: make(map[string]*subscriber),
+	}
+}
+
+// Subscribe used to subscribe given name to a topic, topic can be a pattern such as
+// order.* matched using path.Match.  Deliverer only used on name's first subscription,
+// all topics subscribed by the same name will share the same buffer
+func (b *Bus) Subscribe(name, topic string, deliver Deliverer) error {
+	if _, err := path.Match(topic, ""); err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrEventTopic, err)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return errs.ErrEventBusClosed
+	}
+
+	sub, exist := b.subscribers[name]
+	if !exist {
+		ctx, cancel := context.WithCancel(context.Background())
+		tail := make(chan struct{})
+		close(tail)
+
+		sub = &subscriber{
+			name:    name,
+			topics:  make(map[string]bool),
+			deliver: deliver,
+			queue:   make(chan Event, b.opts.Buffer),
+			ctx:     ctx,
+			cancel:  cancel,
+			tail:    tail,
+		}
+
+		b.subscribers[name] = sub
+		go b.run(sub)
+	}
+
+	sub.topics[topic] = true
+	return nil
+}
+
+// Unsubscribe used to unsubscribe given name from a topic, undelivered events will
+// be dropped when the name has no topics left
+func (b *Bus) Unsubscribe(name, topic string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sub, exist := b.subscribers[name]
+	if !exist {
+		return
+	}
+
+	delete(sub.topics, topic)
+	if len(sub.topics) == 0 {
+		sub.cancel()
+		delete(b.subscribers, name)
+	}
+}
+
+// Topics used to get all topics subscribed by given name
+func (b *Bus) Topics(name string) []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	var topics []string
+	if sub, exist := b.subscribers[name]; exist {
+		for topic := range sub.topics {
+			topics = append(topics, topic)
+		}
+	}
+
+	return topics
+}
+
+// Publish used to publish an event to all subscribers of its topic except its
+// source, it will wait until the event queued by subscribers with full buffer
+func (b *Bus) Publish(e Event) error {
+	return b.PublishContext(context.Background(), e)
+}
+
+// PublishContext used to publish an event like Publish, subscribers which still have
+// a full buffer when the context done will not receive the event and reported as
+// errs.ErrEventBufferFull.  A subscriber with full buffer only delays events published
+// to itself
+func (b *Bus) PublishContext(ctx context.Context, e Event) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return errs.ErrEventBusClosed
+	}
+
+	e.ID = atomic.AddUint64(&b.nextID, 1)
+
+	// each subscriber's turn taken while holding the lock, so events will be queued
+	// in the same order as their ids
+	var turns []publishTurn
+	for name, sub := range b.subscribers {
+		if name != e.Source && sub.match(e.Topic) {
+			turn := publishTurn{sub: sub, prev: sub.tail, done: make(chan struct{})}
+			sub.tail = turn.done
+			turns = append(turns, turn)
+		}
+	}
+	b.mutex.Unlock()
+
+	// waiting without holding the lock, each subscriber waited separately
+	var wg sync.WaitGroup
+	results := make([]error, len(turns))
+	for i, turn := range turns {
+		wg.Add(1)
+		go func(i int, turn publishTurn) {
+			defer wg.Done()
+			results[i] = turn.queue(ctx, e)
+		}(i, turn)
+	}
+
+	wg.Wait()
+
+	var errGroups error
+	for _, err := range results {
+		if err != nil {
+			errGroups = multierror.Append(errGroups, err)
+		}
+	}
+
+	return errGroups
+}
+
+// publishTurn used to queue an event to a subscriber after its previous publisher
+type publishTurn struct {
+	sub  *subscriber
+	prev chan struct{}
+	done chan struct{}
+}
+
+func (t publishTurn) queue(ctx context.Context, e Event) error {
+	select {
+	case <-t.prev:
+	case <-t.sub.ctx.Done():
+		// unsubscribed, its undelivered events will be dropped
+		go t.release()
+		return nil
+	case <-ctx.Done():
+		go t.release()
+		return fmt.Errorf("%w: %s, %q", errs.ErrEventBufferFull, t.sub.name, ctx.Err())
+	}
+
+	defer close(t.done)
+	select {
+	case t.sub.queue <- e:
+	case <-t.sub.ctx.Done():
+		// unsubscribed, its undelivered events will be dropped
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s, %q", errs.ErrEventBufferFull, t.sub.name, ctx.Err())
+	}
+
+	return nil
+}
+
+// release used to pass the turn once the previous publisher done, so a dropped event
+// will not let next publisher's event overtake previous publisher's event
+func (t publishTurn) release() {
+	<-t.prev
+	close(t.done)
+}
+
+// Close used to stop all subscribers, undelivered events will be dropped
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for name, sub := range b.subscribers {
+		sub.cancel()
+		delete(b.subscribers, name)
+	}
+}
+
+func (b *Bus) run(sub *subscriber) {
+	for {
+		select {
+		case <-sub.ctx.Done():
+			return
+		case e := <-sub.queue:
+			if !b.deliver(sub, e) {
+				return
+			}
+		}
+	}
+}
+
+// deliver used to deliver an event until it succeeded or its attempts exhausted, an
+// undelivered event will be passed to dead letter.  It returns false if unsubscribed
+func (b *Bus) deliver(sub *subscriber, e Event) bool {
+	var err error
+	for attempt := 1; attempt <= b.opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-sub.ctx.Done():
+				return false
+			case <-time.After(b.opts.RetryInterval):
+			}
+		}
+
+		err = sub.deliver(sub.ctx, e)
+		if err == nil {
+			return true
+		}
+	}
+
+	if sub.ctx.Err() != nil {
+		return false
+	}
+
+	b.opts.DeadLetter(sub.name, e, fmt.Errorf("%w: %d attempts, %q", errs.ErrEventUndelivered, b.opts.MaxAttempts, err))
+	return true
+}
+
+func logDeadLetter(subscriber string, e Event, err error) {
+	log.Printf("Event %d on %s dropped for %s: %v", e.ID, e.Topic, subscriber, err)
+}
+
+func (s *subscriber) match(topic string) bool {
+	for pattern := range s.topics {
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Encode used to encode an event as plugin's command payload
+func Encode(e Event) ([]byte, error) {
+	return json.Marshal(message{
+		ID:      e.ID,
+		Topic:   e.Topic,
+		Source:  e.Source,
+		Payload: hex.EncodeToString(e.Payload),
+	})
+}
+
+// Decode used by plugins to decode pushed event's payload
+func Decode(b []byte) (Event, error) {
+	var m message
+	err := json.Unmarshal(b, &m)
+	if err != nil {
+		return Event{}, fmt.Errorf("%w: %q", errs.ErrEventPayload, err)
+	}
+
+	payload, err := hex.DecodeString(m.Payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("%w: %q", errs.ErrEventPayload, err)
+	}
+
+	return Event{
+		ID:      m.ID,
+		Topic:   m.Topic,
+		Source:  m.Source,
+		Payload: payload,
+	}, nil
+}
@@ -0,0 +1,311 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func collect(ch chan event.Event) event.Deliverer {
+	return func(_ context.Context, e event.Event) error {
+		ch <- e
+		return nil
+	}
+}
+
+func receive(t *testing.T, ch chan event.Event) event.Event {
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("event has not been delivered")
+	}
+
+	return event.Event{}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	bus := event.NewBus(nil)
+	defer bus.Close()
+
+	ch1 := make(chan event.Event, 10)
+	ch2 := make(chan event.Event, 10)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.*", collect(ch1)))
+	assert.NoError(t, bus.Subscribe("plugin_2", "order.created", collect(ch2)))
+
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.created", Payload: []byte("1")}))
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.paid", Payload: []byte("2")}))
+
+	e := receive(t, ch1)
+	assert.Equal(t, "order.created", e.Topic)
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Equal(t, "order.paid", receive(t, ch1).Topic)
+
+	e = receive(t, ch2)
+	assert.Equal(t, []byte("1"), e.Payload)
+
+	select {
+	case e := <-ch2:
+		t.Fatalf("unexpected event: %s", e.Topic)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPublishSkipSource(t *testing.T) {
+	bus := event.NewBus(nil)
+	defer bus.Close()
+
+	ch := make(chan event.Event, 10)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", collect(ch)))
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.created", Source: "plugin_1"}))
+
+	select {
+	case <-ch:
+		t.Fatal("source should not receive its own event")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDeliveryRetried(t *testing.T) {
+	bus := event.NewBus(&event.Options{RetryInterval: 10 * time.Millisecond})
+	defer bus.Close()
+
+	var mutex sync.Mutex
+	attempts := 0
+	ch := make(chan event.Event, 1)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", func(_ context.Context, e event.Event) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		attempts++
+		if attempts < 3 {
+			return errors.New("error")
+		}
+
+		ch <- e
+		return nil
+	}))
+
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.created"}))
+	receive(t, ch)
+
+	mutex.Lock()
+	assert.Equal(t, 3, attempts)
+	mutex.Unlock()
+}
+
+func TestPublishBufferFull(t *testing.T) {
+	bus := event.NewBus(&event.Options{Buffer: 1})
+	defer bus.Close()
+
+	block := make(chan struct{})
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", func(ctx context.Context, _ event.Event) error {
+		select {
+		case <-block:
+		case <-ctx.Done():
+		}
+		return nil
+	}))
+	defer close(block)
+
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		err = bus.PublishContext(ctx, event.Event{Topic: "order.created"})
+		cancel()
+	}
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrEventBufferFull))
+}
+
+func TestPublishWaitForBuffer(t *testing.T) {
+	bus := event.NewBus(&event.Options{Buffer: 1})
+	defer bus.Close()
+
+	ch := make(chan event.Event, 10)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", func(_ context.Context, e event.Event) error {
+		time.Sleep(10 * time.Millisecond)
+		ch <- e
+		return nil
+	}))
+
+	// slow subscriber should not lose any events
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bus.Publish(event.Event{Topic: "order.created"}))
+	}
+
+	for i := 1; i <= 5; i++ {
+		assert.Equal(t, uint64(i), receive(t, ch).ID)
+	}
+}
+
+func TestPublishStalledSubscriber(t *testing.T) {
+	bus := event.NewBus(&event.Options{Buffer: 1})
+	defer bus.Close()
+
+	stalled := make(chan struct{})
+	defer close(stalled)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", func(_ context.Context, _ event.Event) error {
+		<-stalled
+		return nil
+	}))
+
+	ch := make(chan event.Event, 10)
+	assert.NoError(t, bus.Subscribe("plugin_2", "order.paid", func(_ context.Context, e event.Event) error {
+		ch <- e
+		return nil
+	}))
+
+	// fill plugin_1's buffer, next publisher will wait for plugin_1
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.created"}))
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.created"}))
+
+	waiting := make(chan error)
+	go func() {
+		waiting <- bus.Publish(event.Event{Topic: "order.created"})
+	}()
+
+	// publishers of other subscribers should not be blocked by plugin_1
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	assert.NoError(t, bus.PublishContext(ctx, event.Event{Topic: "order.paid"}))
+	assert.Equal(t, "order.paid", receive(t, ch).Topic)
+
+	select {
+	case <-waiting:
+		t.Fatal("publisher should still wait for plugin_1")
+	default:
+	}
+
+	bus.Unsubscribe("plugin_1", "order.created")
+	assert.NoError(t, <-waiting)
+}
+
+func TestPublishUnsubscribedWhileWaiting(t *testing.T) {
+	bus := event.NewBus(&event.Options{Buffer: 1})
+	defer bus.Close()
+
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", func(ctx context.Context, _ event.Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		bus.Unsubscribe("plugin_1", "order.created")
+	}()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bus.Publish(event.Event{Topic: "order.created"}))
+	}
+}
+
+func TestDeliveryDeadLetter(t *testing.T) {
+	type deadLetter struct {
+		subscriber string
+		event      event.Event
+		err        error
+	}
+
+	dead := make(chan deadLetter, 1)
+	bus := event.NewBus(&event.Options{
+		RetryInterval: 10 * time.Millisecond,
+		MaxAttempts:   2,
+		DeadLetter: func(subscriber string, e event.Event, err error) {
+			dead <- deadLetter{subscriber, e, err}
+		},
+	})
+	defer bus.Close()
+
+	var mutex sync.Mutex
+	attempts := 0
+	ch := make(chan event.Event, 1)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.*", func(_ context.Context, e event.Event) error {
+		if e.Topic == "order.created" {
+			mutex.Lock()
+			attempts++
+			mutex.Unlock()
+			return errors.New("poison")
+		}
+
+		ch <- e
+		return nil
+	}))
+
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.created"}))
+	assert.NoError(t, bus.Publish(event.Event{Topic: "order.paid"}))
+
+	// poison event should not block next events
+	assert.Equal(t, "order.paid", receive(t, ch).Topic)
+
+	select {
+	case d := <-dead:
+		assert.Equal(t, "plugin_1", d.subscriber)
+		assert.Equal(t, "order.created", d.event.Topic)
+		assert.True(t, errors.Is(d.err, errs.ErrEventUndelivered))
+		assert.Contains(t, d.err.Error(), "poison")
+	case <-time.After(2 * time.Second):
+		t.Fatal("event has not been dead lettered")
+	}
+
+	mutex.Lock()
+	assert.Equal(t, 2, attempts)
+	mutex.Unlock()
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := event.NewBus(nil)
+	defer bus.Close()
+
+	ch := make(chan event.Event, 10)
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.created", collect(ch)))
+	assert.NoError(t, bus.Subscribe("plugin_1", "order.paid", collect(ch)))
+	assert.ElementsMatch(t, []string{"order.created", "order.paid"}, bus.Topics("plugin_1"))
+
+	bus.Unsubscribe("plugin_1", "order.created")
+	assert.Equal(t, []string{"order.paid"}, bus.Topics("plugin_1"))
+
+	bus.Unsubscribe("plugin_1", "order.paid")
+	assert.Empty(t, bus.Topics("plugin_1"))
+}
+
+func TestSubscribeInvalidTopic(t *testing.T) {
+	bus := event.NewBus(nil)
+	defer bus.Close()
+
+	err := bus.Subscribe("plugin_1", "[", collect(make(chan event.Event)))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrEventTopic))
+}
+
+func TestClosed(t *testing.T) {
+	bus := event.NewBus(nil)
+	bus.Close()
+
+	err := bus.Publish(event.Event{Topic: "order.created"})
+	assert.True(t, errors.Is(err, errs.ErrEventBusClosed))
+
+	err = bus.Subscribe("plugin_1", "order.created", collect(make(chan event.Event)))
+	assert.True(t, errors.Is(err, errs.ErrEventBusClosed))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	b, err := event.Encode(event.Event{ID: 1, Topic: "order.created", Source: "plugin_1", Payload: []byte("hello")})
+	assert.NoError(t, err)
+
+	e, err := event.Decode(b)
+	assert.NoError(t, err)
+	assert.Equal(t, event.Event{ID: 1, Topic: "order.created", Source: "plugin_1", Payload: []byte("hello")}, e)
+
+	_, err = event.Decode([]byte("invalid"))
+	assert.True(t, errors.Is(err, errs.ErrEventPayload))
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// CommandEvent used as plugin's command to receive pushed events, payload
+	// encoded using Encode
+	CommandEvent = "goplugin.event"
+
+	// DefaultBuffer used as default subscriber's buffer size
+	DefaultBuffer = 100
+
+	// DefaultRetryInterval used as default interval between failed deliveries
+	DefaultRetryInterval = time.Second
+
+	// DefaultMaxAttempts used as default maximum delivery attempts per event
+	DefaultMaxAttempts = 10
+)
+
+// Event used as main data published on a topic.  ID assigned by the bus and
+// Source is the publisher's name, empty if published by the host
+type Event struct {
+	ID      uint64
+	Topic   string
+	Source  string
+	Payload []byte
+}
+
+// Deliverer used to deliver an event to a subscriber, a failed delivery will be
+// retried until it succeeded, its attempts exhausted or the subscriber unsubscribed
+type Deliverer func(ctx context.Context, e Event) error
+
+// DeadLetter used to receive events which still cannot be delivered to a subscriber
+// after all attempts, given error wraps errs.ErrEventUndelivered and the last cause
+type DeadLetter func(subscriber string, e Event, err error)
+
+// Options used to configure bus's delivery.  Buffer used as maximum undelivered
+// events per subscriber, publishers will wait for subscribers with full buffer.
+// MaxAttempts used as maximum delivery attempts per event, undelivered events will
+// be passed to DeadLetter or logged if it's not defined
+type Options struct {
+	Buffer        int
+	RetryInterval time.Duration
+	MaxAttempts   int
+	DeadLetter    DeadLetter
+}
+
+// message used as event's wire format, payload encoded in hex
+type message struct {
+	ID      uint64 `json:"id"`
+	Topic   string `json:"topic"`
+	Source  string `json:"source,omitempty"`
+	Payload string `json:"payload"`
+}
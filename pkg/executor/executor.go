@@ -2,8 +2,14 @@ package executor
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/quadroops/goplugin/pkg/caller"
+	// builtin protocols registered by caller's driver
+	_ "github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/discover"
 	"github.com/quadroops/goplugin/pkg/errs"
 	"github.com/quadroops/goplugin/pkg/host"
 	"github.com/quadroops/goplugin/pkg/process"
@@ -13,8 +19,9 @@ import (
 // Register used to register a host and their isolated processes
 func Register(h *host.Builder, proc *process.Instance) *Registry {
 	return &Registry{
-		Host:    h,
-		Process: proc,
+		Host:     h,
+		Process:  proc,
+		Breakers: caller.NewBreakers(),
 	}
 }
 
@@ -69,8 +76,10 @@ func (e *Exec) FromHost(host string) (*Container, error) {
 		return nil, err
 	}
 
-	// setup retry timeout
+	// setup retry timeout & policy
 	container.retryTimeout = e.options.RetryTimeout
+	container.retryPolicy = e.options.RetryPolicy
+	container.breaker = e.options.Breaker
 	return &container, nil
 }
 
@@ -90,6 +99,27 @@ func (c *Container) Setup() error {
 	return nil
 }
 
+// ValidateProtocols used to make sure all installed plugins are using registered
+// protocols, so a misconfigured plugin will be reported before it's called
+func (c *Container) ValidateProtocols() error {
+	var names []string
+	for name := range c.plugins {
+		names = append(names, string(name))
+	}
+
+	sort.Strings(names)
+
+	var errGroups error
+	for _, name := range names {
+		protocol := c.plugins[host.PluginName(name)].ProtocolType
+		if !caller.IsProtocolRegistered(protocol) {
+			errGroups = multierror.Append(errGroups, fmt.Errorf("%w: %q used by %s", errs.ErrProtocolUnknown, protocol, name))
+		}
+	}
+
+	return errGroups
+}
+
 // GetAllPlugins used to retrive installed plugins from requested host
 func (c *Container) GetAllPlugins() host.Plugins {
 	return c.plugins
@@ -117,8 +147,30 @@ func (c *Container) Run(name string, port int) error {
 		return errs.ErrPluginNotFound
 	}
 
-	pluginCh, err := c.Registry.Process.Run(
-		pluginMeta.ExecTime,
+	// no need to start a plugin which can't be called
+	if !caller.IsProtocolRegistered(pluginMeta.ProtocolType) {
+		return fmt.Errorf("%w: %q", errs.ErrProtocolUnknown, pluginMeta.ProtocolType)
+	}
+
+	ready, err := newReadiness(pluginMeta)
+	if err != nil {
+		return err
+	}
+
+	env := process.Environment{
+		Env:         pluginMeta.Env,
+		Passthrough: pluginMeta.EnvPassthrough,
+		Files:       pluginMeta.EnvFromFile,
+		Secrets:     pluginMeta.Secrets,
+		Clear:       pluginMeta.ClearEnv,
+		Workdir:     pluginMeta.Workdir,
+		Limits:      limits(pluginMeta.Limits),
+	}
+
+	pluginCh, err := c.Registry.Process.RunProtocolEnv(
+		pluginMeta.ProtocolType,
+		env,
+		ready.execTime(pluginMeta),
 		name,
 		pluginMeta.ExecPath,
 		port,
@@ -128,30 +180,69 @@ func (c *Container) Run(name string, port int) error {
 		return err
 	}
 
-	return c.Registry.Process.RegisterNewProcess(pluginCh)
+	plugin := <-pluginCh
+
+	// plugin's handshake must use the same protocol defined on its metadata
+	if plugin.Handshake != nil && plugin.Handshake.Protocol != pluginMeta.ProtocolType {
+		if plugin.Kill != nil {
+			plugin.Kill()
+		}
+
+		return fmt.Errorf("%w: plugin uses protocol %q, expected %q", errs.ErrPluginHandshake, plugin.Handshake.Protocol, pluginMeta.ProtocolType)
+	}
+
+	err = c.Registry.Process.Register(plugin)
+	if err != nil {
+		return err
+	}
+
+	err = ready.waitProcess(plugin)
+	if err != nil {
+		c.Registry.Process.Kill(name)
+		return err
+	}
+
+	return nil
 }
 
-// Get used to create plugin's instance
+// Get used to create plugin's instance using container's options
 func (c *Container) Get(name string, port int, builder caller.Builder) (*caller.Plugin, error) {
+	return c.GetWithOptions(name, port, builder, nil)
+}
+
+// GetWithOptions used to create plugin's instance using given plugin's options, any
+// undefined option will fallback to container's options
+func (c *Container) GetWithOptions(name string, port int, builder caller.Builder, opts *PluginOptions) (*caller.Plugin, error) {
 	pluginMeta, exist := c.plugins[host.PluginName(name)]
 	if !exist {
 		return nil, errs.ErrPluginNotFound
 	}
 
 	// need to make sure if current plugin's rpc type supported
-	var protocolAllowed bool
-	for _, protocol := range caller.AllowedProtocols {
-		if pluginMeta.ProtocolType == protocol {
-			protocolAllowed = true
-			break
-		}
+	if !caller.IsProtocolRegistered(pluginMeta.ProtocolType) {
+		return nil, errs.ErrProtocolUnknown
 	}
 
-	if !protocolAllowed {
-		return nil, errs.ErrProtocolUnknown
+	transporter := builder(pluginMeta.ProtocolType, port)
+	if transporter == nil {
+		return nil, fmt.Errorf("%w: cannot build %q caller", errs.ErrProtocolUnknown, pluginMeta.ProtocolType)
+	}
+
+	if opts == nil {
+		opts = &PluginOptions{}
 	}
 
-	return caller.New(pluginMeta, builder(pluginMeta.ProtocolType, port), c.retryTimeout), nil
+	policy := opts.RetryPolicy
+	if policy == nil {
+		policy = c.retryPolicy
+	}
+
+	if policy == nil {
+		policy = caller.DefaultRetryPolicy(c.retryTimeout)
+	}
+
+	plugin := caller.NewWithRetryPolicy(pluginMeta, transporter, policy)
+	return plugin.WithBreaker(c.getBreaker(name, opts.Breaker)), nil
 }
 
 // GetPluginMeta used to get plugin's metadata
@@ -163,3 +254,50 @@ func (c *Container) GetPluginMeta(name string) (*host.Registry, error) {
 
 	return pluginMeta, nil
 }
+
+// BreakerState used to get plugin's circuit breaker state, a plugin without
+// circuit breaker will always be closed
+func (c *Container) BreakerState(name string) caller.BreakerState {
+	breaker := c.getBreaker(name, nil)
+	if breaker == nil {
+		return caller.BreakerClosed
+	}
+
+	return breaker.State()
+}
+
+// ResetBreaker used to close plugin's circuit breaker, such as after its process
+// has been restarted
+func (c *Container) ResetBreaker(name string) {
+	breaker := c.getBreaker(name, nil)
+	if breaker != nil {
+		breaker.Reset()
+	}
+}
+
+func (c *Container) getBreaker(name string, opts *caller.BreakerOptions) *caller.Breaker {
+	if c.Registry.Breakers == nil {
+		return nil
+	}
+
+	if opts == nil {
+		opts = c.breaker
+	}
+
+	return c.Registry.Breakers.Get(name, opts)
+}
+
+func limits(l *discover.PluginLimits) *process.Limits {
+	if l == nil {
+		return nil
+	}
+
+	return &process.Limits{
+		Memory:    l.Memory,
+		CPUTime:   l.CPUTime,
+		OpenFiles: l.OpenFiles,
+		Processes: l.Processes,
+		CoreSize:  l.CoreSize,
+		CPU:       l.CPU,
+	}
+}
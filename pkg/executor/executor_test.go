@@ -3,6 +3,7 @@ package executor_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/quadroops/goplugin/pkg/caller"
@@ -234,6 +235,45 @@ func TestRunSuccess(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRunHandshakeProtocolMismatch(t *testing.T) {
+	toml, err := discoverDriver.NewTomlParser().Parse([]byte(tomlContent))
+	assert.NoError(t, err)
+
+	md5 := new(hostMock.MD5Checker)
+	md5.On("Parse", mock.Anything).Return("d41d8cd98f00b204e9800998ecf8427e", nil)
+
+	h := host.New("host_1", toml, md5)
+
+	var killed bool
+	mockPlugin := createMockPlugin("test")
+	mockPlugin.Kill = func() { killed = true }
+	mockPlugin.Handshake = &process.Handshake{Protocol: "rest", Version: 1}
+
+	runner := new(processMock.Runner)
+	runner.On("Run", 5, "name_1", "./tmp/test", 1001).Once().Return(createMockChanPlugin(mockPlugin), nil)
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("IsExist", "name_1").Once().Return(false)
+
+	p := process.New(runner, processes)
+
+	exec := executor.New(
+		&executor.Options{
+			RetryTimeout: 3,
+		},
+		executor.Register(h, p),
+	)
+
+	container1, err := exec.FromHost("host_1")
+	assert.NoError(t, err)
+
+	err = container1.Run("name_1", 1001)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginHandshake))
+	assert.True(t, killed)
+	processes.AssertNotCalled(t, "Add", mock.Anything)
+}
+
 func TestRunNoPlugin(t *testing.T) {
 	toml, err := discoverDriver.NewTomlParser().Parse([]byte(tomlContent))
 	assert.NoError(t, err)
@@ -419,3 +459,145 @@ func TestAllowedProtocolFailed(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, errs.ErrProtocolUnknown))
 }
+
+func TestGetPluginBreakerPersisted(t *testing.T) {
+	mockCaller := new(callerMock.Caller)
+	mockCaller.On("PingContext", mock.Anything).Return("", errs.ErrPluginPing)
+
+	toml, err := discoverDriver.NewTomlParser().Parse([]byte(tomlContent))
+	assert.NoError(t, err)
+
+	md5 := new(hostMock.MD5Checker)
+	md5.On("Parse", mock.Anything).Return("d41d8cd98f00b204e9800998ecf8427e", nil)
+
+	h := host.New("host_1", toml, md5)
+	runner := new(processMock.Runner)
+	processes := new(processMock.ProcessesBuilder)
+	p := process.New(runner, processes)
+
+	exec := executor.New(
+		&executor.Options{
+			RetryTimeout: 3,
+			Breaker: &caller.BreakerOptions{
+				ConsecutiveFailures: 1,
+			},
+		},
+		executor.Register(h, p),
+	)
+
+	container1, err := exec.FromHost("host_1")
+	assert.NoError(t, err)
+
+	plugin, err := container1.Get("name_1", 1001, func(rpcType string, port int) caller.Caller {
+		return mockCaller
+	})
+	assert.NoError(t, err)
+
+	_, err = plugin.Ping()
+	assert.Error(t, err)
+
+	container2, err := exec.FromHost("host_1")
+	assert.NoError(t, err)
+	assert.Equal(t, caller.BreakerOpen, container2.BreakerState("name_1"))
+
+	container2.ResetBreaker("name_1")
+	assert.Equal(t, caller.BreakerClosed, container1.BreakerState("name_1"))
+}
+
+func TestRegisteredProtocolSuccess(t *testing.T) {
+	caller.RegisterProtocol("custom", func(opts interface{}) (caller.Caller, error) {
+		return new(callerMock.Caller), nil
+	})
+
+	content := strings.Replace(tomlContent, `comm_type = "unknown"`, `comm_type = "custom"`, 1)
+	toml, err := discoverDriver.NewTomlParser().Parse([]byte(content))
+	assert.NoError(t, err)
+
+	md5 := new(hostMock.MD5Checker)
+	md5.On("Parse", mock.Anything).Return("d41d8cd98f00b204e9800998ecf8427e", nil)
+
+	h := host.New("host_1", toml, md5)
+	p := process.New(new(processMock.Runner), new(processMock.ProcessesBuilder))
+
+	exec := executor.New(
+		&executor.Options{
+			RetryTimeout: 3,
+		},
+		executor.Register(h, p),
+	)
+
+	container, err := exec.FromHost("host_1")
+	assert.NoError(t, err)
+
+	plugin, err := container.Get("name_3", 1001, func(rpcType string, port int) caller.Caller {
+		c, _ := caller.NewProtocol(rpcType, nil)
+		return c
+	})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, plugin)
+}
+
+func TestRunUnknownProtocol(t *testing.T) {
+	toml, err := discoverDriver.NewTomlParser().Parse([]byte(tomlContent))
+	assert.NoError(t, err)
+
+	md5 := new(hostMock.MD5Checker)
+	md5.On("Parse", mock.Anything).Return("d41d8cd98f00b204e9800998ecf8427e", nil)
+
+	h := host.New("host_3", toml, md5)
+	runner := new(processMock.Runner)
+	p := process.New(runner, new(processMock.ProcessesBuilder))
+
+	exec := executor.New(
+		&executor.Options{
+			RetryTimeout: 3,
+		},
+		executor.Register(h, p),
+	)
+
+	container, err := exec.FromHost("host_3")
+	assert.NoError(t, err)
+
+	err = container.Run("name_3", 1001)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrProtocolUnknown))
+	runner.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestValidateProtocols(t *testing.T) {
+	md5 := new(hostMock.MD5Checker)
+	md5.On("Parse", mock.Anything).Return("d41d8cd98f00b204e9800998ecf8427e", nil)
+
+	testCases := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"unknown", tomlContent, false},
+		{"registered", strings.Replace(tomlContent, `comm_type = "unknown"`, `comm_type = "stdio"`, 1), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			toml, err := discoverDriver.NewTomlParser().Parse([]byte(tc.content))
+			assert.NoError(t, err)
+
+			h := host.New("host_1", toml, md5)
+			p := process.New(new(processMock.Runner), new(processMock.ProcessesBuilder))
+			exec := executor.New(&executor.Options{RetryTimeout: 3}, executor.Register(h, p))
+
+			container, err := exec.FromHost("host_1")
+			assert.NoError(t, err)
+
+			err = container.ValidateProtocols()
+			if tc.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, errs.ErrProtocolUnknown))
+			assert.Contains(t, err.Error(), "name_3")
+		})
+	}
+}
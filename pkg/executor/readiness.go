@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/host"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+const (
+	// ReadinessSleep used to sleep for plugin's exec time, used as default strategy
+	ReadinessSleep = "sleep"
+
+	// ReadinessPing used to poll plugin's ping until it succeeded
+	ReadinessPing = "ping"
+
+	// ReadinessTCP used to wait plugin's tcp address accepting connections
+	ReadinessTCP = "tcp"
+
+	// ReadinessSocket used to wait plugin's unix domain socket accepting connections
+	ReadinessSocket = "socket"
+
+	// ReadinessHandshake used to wait plugin's launch handshake, the runner must be
+	// configured using handshake options
+	ReadinessHandshake = "handshake"
+
+	// DefaultReadinessInterval used as default interval between probes
+	DefaultReadinessInterval = 100 * time.Millisecond
+
+	// DefaultReadinessTimeout used as probe's timeout when neither readiness's timeout
+	// nor plugin's exec time defined
+	DefaultReadinessTimeout = 10 * time.Second
+
+	unixSchema = "unix://"
+)
+
+// readiness used to resolve plugin's readiness configurations, plugin's exec time
+// used as readiness's timeout if it's not defined, and as its maximum timeout
+type readiness struct {
+	strategy string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func newReadiness(meta *host.Registry) (readiness, error) {
+	r := readiness{
+		strategy: ReadinessSleep,
+		timeout:  time.Duration(meta.ExecTime) * time.Second,
+		interval: DefaultReadinessInterval,
+	}
+
+	if meta.Readiness != nil {
+		if meta.Readiness.Strategy != "" {
+			r.strategy = meta.Readiness.Strategy
+		}
+
+		// plugin's exec time is the longest time a plugin can take to start
+		timeout := time.Duration(meta.Readiness.Timeout) * time.Millisecond
+		if timeout > 0 && (r.timeout <= 0 || timeout < r.timeout) {
+			r.timeout = timeout
+		}
+
+		if meta.Readiness.Interval > 0 {
+			r.interval = time.Duration(meta.Readiness.Interval) * time.Millisecond
+		}
+	}
+
+	switch r.strategy {
+	case ReadinessSleep, ReadinessPing, ReadinessTCP, ReadinessSocket, ReadinessHandshake:
+	default:
+		return r, fmt.Errorf("%w: unknown strategy %q", errs.ErrPluginNotReady, r.strategy)
+	}
+
+	if r.timeout <= 0 {
+		r.timeout = DefaultReadinessTimeout
+	}
+
+	return r, nil
+}
+
+// execTime used to get runner's waiting time, plugins using probes don't need to
+// wait, except for handshake which uses it as handshake's timeout
+func (r readiness) execTime(meta *host.Registry) int {
+	switch r.strategy {
+	case ReadinessSleep, ReadinessHandshake:
+		return meta.ExecTime
+	}
+
+	return 0
+}
+
+// poll used to call given probe until it succeeded or readiness's timeout reached
+func (r readiness) poll(probe func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		err := probe(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s probe timeout after %s: %q", errs.ErrPluginNotReady, r.strategy, r.timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitProcess used to check plugin's readiness which has been waited by the runner
+func (r readiness) waitProcess(plugin process.Plugin) error {
+	if r.strategy == ReadinessHandshake && plugin.Handshake == nil {
+		return fmt.Errorf("%w: handshake strategy requires runner's handshake", errs.ErrPluginNotReady)
+	}
+
+	return nil
+}
+
+// waitAddress used to wait plugin's tcp address or unix socket accepting connections.
+// Given address is the one used by plugin's caller, plugin's reported address or its
+// port will be used if the caller doesn't know plugin's address
+func (r readiness) waitAddress(address string, plugin process.Plugin, port int) error {
+	if address == "" {
+		address = plugin.Address
+	}
+
+	if r.strategy == ReadinessSocket {
+		if !strings.HasPrefix(address, unixSchema) {
+			return fmt.Errorf("%w: socket strategy requires plugin's unix socket", errs.ErrPluginNotReady)
+		}
+
+		return r.poll(dialer("unix", strings.TrimPrefix(address, unixSchema)))
+	}
+
+	if address == "" || strings.HasPrefix(address, unixSchema) {
+		address = strconv.Itoa(port)
+	}
+
+	// plugin may only report its port
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort("127.0.0.1", address)
+	}
+
+	return r.poll(dialer("tcp", address))
+}
+
+func dialer(network, address string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, network, address)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+}
+
+// WaitReady used to wait plugin's ping, tcp or socket readiness after the plugin started.
+// Plugin's caller must be ready to be built using given builder, and given address used
+// as the address called by plugin's caller, formatted as host:port or unix:// prefixed
+// socket's path.  It will do nothing for plugins using other strategies.  Plugin will be
+// killed if it's not ready
+func (c *Container) WaitReady(name, address string, port int, builder caller.Builder) error {
+	pluginMeta, exist := c.plugins[host.PluginName(name)]
+	if !exist {
+		return errs.ErrPluginNotFound
+	}
+
+	r, err := newReadiness(pluginMeta)
+	if err != nil {
+		return err
+	}
+
+	switch r.strategy {
+	case ReadinessPing:
+		transporter := builder(pluginMeta.ProtocolType, port)
+		if transporter == nil {
+			return fmt.Errorf("%w: cannot build %q caller", errs.ErrProtocolUnknown, pluginMeta.ProtocolType)
+		}
+
+		err = r.poll(func(ctx context.Context) error {
+			_, err := transporter.PingContext(ctx)
+			return err
+		})
+	case ReadinessTCP, ReadinessSocket:
+		var plugin process.Plugin
+		plugin, err = c.Registry.Process.GetPlugin(name)
+		if err != nil {
+			return err
+		}
+
+		err = r.waitAddress(address, plugin, port)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		c.Registry.Process.Kill(name)
+		return err
+	}
+
+	return nil
+}
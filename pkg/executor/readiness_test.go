@@ -0,0 +1,263 @@
+package executor_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/caller"
+	discoverDriver "github.com/quadroops/goplugin/pkg/discover/driver"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/executor"
+	"github.com/quadroops/goplugin/pkg/host"
+	"github.com/quadroops/goplugin/pkg/process"
+
+	callerMock "github.com/quadroops/goplugin/pkg/caller/mocks"
+	hostMock "github.com/quadroops/goplugin/pkg/host/mocks"
+	processMock "github.com/quadroops/goplugin/pkg/process/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const readinessContent = `
+	[meta]
+	version = "1.0.0"
+	author = "hiraq|hiraq@ruangguru.com"
+
+	[settings]
+	debug = true
+
+	[plugins]
+
+		[plugins.name_1]
+		author = "author_1|author_1@gmail.com"
+		md5 = "d41d8cd98f00b204e9800998ecf8427e"
+		exec = "./tmp/test"
+		exec_file = "./tmp/test"
+		exec_time = 5
+		comm_type = "rest"
+
+			[plugins.name_1.readiness]
+			strategy = "%s"
+			timeout = 300
+			interval = 10
+
+	[hosts]
+
+		[hosts.host_1]
+		plugins = ["name_1"]
+	`
+
+func newReadinessContainer(t *testing.T, strategy string, runner process.Runner, processes process.ProcessesBuilder) *executor.Container {
+	return newReadinessContainerContent(t, fmt.Sprintf(readinessContent, strategy), runner, processes)
+}
+
+func newReadinessContainerContent(t *testing.T, content string, runner process.Runner, processes process.ProcessesBuilder) *executor.Container {
+	toml, err := discoverDriver.NewTomlParser().Parse([]byte(content))
+	assert.NoError(t, err)
+
+	md5 := new(hostMock.MD5Checker)
+	md5.On("Parse", mock.Anything).Return("d41d8cd98f00b204e9800998ecf8427e", nil)
+
+	h := host.New("host_1", toml, md5)
+	exec := executor.New(
+		&executor.Options{
+			RetryTimeout: 3,
+		},
+		executor.Register(h, process.New(runner, processes)),
+	)
+
+	container, err := exec.FromHost("host_1")
+	assert.NoError(t, err)
+	return container
+}
+
+func TestRunReadinessTCPNoExecTime(t *testing.T) {
+	mockPlugin := createMockPlugin("name_1")
+
+	// probed plugin should not wait for its exec time
+	runner := new(processMock.Runner)
+	runner.On("Run", 0, "name_1", "./tmp/test", 0).Once().Return(createMockChanPlugin(mockPlugin), nil)
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("IsExist", "name_1").Once().Return(false)
+	processes.On("Add", mock.Anything).Once().Return(nil)
+
+	container := newReadinessContainer(t, executor.ReadinessTCP, runner, processes)
+	err := container.Run("name_1", 0)
+	assert.NoError(t, err)
+	runner.AssertExpectations(t)
+}
+
+func TestWaitReadyTCPSuccess(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	testCases := []struct {
+		name     string
+		address  string
+		reported string
+	}{
+		{"caller's address", listener.Addr().String(), ""},
+		{"reported address", "", listener.Addr().String()},
+		{"reported port", "", port},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPlugin := createMockPlugin("name_1")
+			mockPlugin.Address = tc.reported
+
+			processes := new(processMock.ProcessesBuilder)
+			processes.On("Get", "name_1").Once().Return(mockPlugin, nil)
+
+			container := newReadinessContainer(t, executor.ReadinessTCP, new(processMock.Runner), processes)
+			err := container.WaitReady("name_1", tc.address, 0, nil)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWaitReadyTCPTimeout(t *testing.T) {
+	// reserve a free port and close it, so nothing accepts its connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	var killed bool
+	mockPlugin := createMockPlugin("name_1")
+	mockPlugin.Kill = func() { killed = true }
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("Get", "name_1").Twice().Return(mockPlugin, nil)
+	processes.On("Remove", "name_1").Once().Return(nil)
+
+	container := newReadinessContainer(t, executor.ReadinessTCP, new(processMock.Runner), processes)
+	err = container.WaitReady("name_1", address, 0, nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginNotReady))
+	assert.True(t, killed)
+}
+
+func TestWaitReadySocketRequired(t *testing.T) {
+	mockPlugin := createMockPlugin("name_1")
+	mockPlugin.Kill = func() {}
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("Get", "name_1").Twice().Return(mockPlugin, nil)
+	processes.On("Remove", "name_1").Once().Return(nil)
+
+	container := newReadinessContainer(t, executor.ReadinessSocket, new(processMock.Runner), processes)
+	err := container.WaitReady("name_1", "127.0.0.1:8081", 8081, nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginNotReady))
+}
+
+func TestRunReadinessHandshakeRequired(t *testing.T) {
+	mockPlugin := createMockPlugin("name_1")
+	mockPlugin.Kill = func() {}
+
+	runner := new(processMock.Runner)
+	runner.On("Run", 5, "name_1", "./tmp/test", 1001).Once().Return(createMockChanPlugin(mockPlugin), nil)
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("IsExist", "name_1").Once().Return(false)
+	processes.On("Add", mock.Anything).Once().Return(nil)
+	processes.On("Get", "name_1").Once().Return(mockPlugin, nil)
+	processes.On("Remove", "name_1").Once().Return(nil)
+
+	container := newReadinessContainer(t, executor.ReadinessHandshake, runner, processes)
+	err := container.Run("name_1", 1001)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginNotReady))
+}
+
+func TestRunReadinessUnknownStrategy(t *testing.T) {
+	runner := new(processMock.Runner)
+	processes := new(processMock.ProcessesBuilder)
+
+	container := newReadinessContainer(t, "unknown", runner, processes)
+	err := container.Run("name_1", 1001)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginNotReady))
+	runner.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWaitReadyPingSuccess(t *testing.T) {
+	transporter := new(callerMock.Caller)
+	transporter.On("PingContext", mock.Anything).Once().Return("", errs.ErrPluginPing)
+	transporter.On("PingContext", mock.Anything).Once().Return("pong", nil)
+
+	container := newReadinessContainer(t, executor.ReadinessPing, new(processMock.Runner), new(processMock.ProcessesBuilder))
+	err := container.WaitReady("name_1", "127.0.0.1:8081", 8081, func(commType string, port int) caller.Caller {
+		return transporter
+	})
+
+	assert.NoError(t, err)
+	transporter.AssertNumberOfCalls(t, "PingContext", 2)
+}
+
+func TestWaitReadyPingTimeout(t *testing.T) {
+	transporter := new(callerMock.Caller)
+	transporter.On("PingContext", mock.Anything).Return("", errs.ErrPluginPing)
+
+	var killed bool
+	mockPlugin := createMockPlugin("name_1")
+	mockPlugin.Kill = func() { killed = true }
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("Get", "name_1").Once().Return(mockPlugin, nil)
+	processes.On("Remove", "name_1").Once().Return(nil)
+
+	container := newReadinessContainer(t, executor.ReadinessPing, new(processMock.Runner), processes)
+	err := container.WaitReady("name_1", "127.0.0.1:8081", 8081, func(commType string, port int) caller.Caller {
+		return transporter
+	})
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginNotReady))
+	assert.True(t, killed)
+}
+
+func TestWaitReadySkipped(t *testing.T) {
+	container := newReadinessContainer(t, executor.ReadinessSleep, new(processMock.Runner), new(processMock.ProcessesBuilder))
+	err := container.WaitReady("name_1", "127.0.0.1:8081", 8081, func(commType string, port int) caller.Caller {
+		t.Fatal("caller should not be built")
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestWaitReadyTimeoutClampedToExecTime(t *testing.T) {
+	transporter := new(callerMock.Caller)
+	transporter.On("PingContext", mock.Anything).Return("", errs.ErrPluginPing)
+
+	mockPlugin := createMockPlugin("name_1")
+	mockPlugin.Kill = func() {}
+
+	processes := new(processMock.ProcessesBuilder)
+	processes.On("Get", "name_1").Once().Return(mockPlugin, nil)
+	processes.On("Remove", "name_1").Once().Return(nil)
+
+	content := fmt.Sprintf(readinessContent, executor.ReadinessPing)
+	content = strings.Replace(content, "exec_time = 5", "exec_time = 1", 1)
+	content = strings.Replace(content, "timeout = 300", "timeout = 60000", 1)
+
+	container := newReadinessContainerContent(t, content, new(processMock.Runner), processes)
+	start := time.Now()
+	err := container.WaitReady("name_1", "127.0.0.1:8081", 8081, func(commType string, port int) caller.Caller {
+		return transporter
+	})
+
+	assert.True(t, errors.Is(err, errs.ErrPluginNotReady))
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
@@ -1,15 +1,18 @@
 package executor
 
 import (
+	"github.com/quadroops/goplugin/pkg/caller"
 	"github.com/quadroops/goplugin/pkg/host"
 	"github.com/quadroops/goplugin/pkg/process"
 	"github.com/reactivex/rxgo/v2"
 )
 
-// Registry used when register new host and their isolated process
+// Registry used when register new host and their isolated process.  Breakers
+// used to keep plugin's circuit breakers alive between containers
 type Registry struct {
-	Host    *host.Builder
-	Process *process.Instance
+	Host     *host.Builder
+	Process  *process.Instance
+	Breakers *caller.Breakers
 }
 
 // Exec used main object of executor
@@ -18,16 +21,28 @@ type Exec struct {
 	options   *Options
 }
 
-// Options for now used only to store retry timeout which will
-// be used by Container
+// Options used to store caller's retry & circuit breaker configurations which will
+// be used by Container.  When RetryPolicy is not defined, RetryTimeout
+// will be used as initial interval of default retry policy.  Circuit breaker
+// will be disabled if Breaker is not defined
 type Options struct {
 	RetryTimeout int
+	RetryPolicy  *caller.RetryPolicy
+	Breaker      *caller.BreakerOptions
+}
+
+// PluginOptions used to override container's options for a single plugin
+type PluginOptions struct {
+	RetryPolicy *caller.RetryPolicy
+	Breaker     *caller.BreakerOptions
 }
 
 // Container used as main object to start host's processes
 type Container struct {
 	installed    bool
 	retryTimeout int
+	retryPolicy  *caller.RetryPolicy
+	breaker      *caller.BreakerOptions
 	Registry     *Registry
 	plugins      host.Plugins
 }
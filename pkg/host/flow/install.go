@@ -1,6 +1,10 @@
 package flow
 
-import "os"
+import (
+	"os"
+
+	"github.com/quadroops/goplugin/pkg/discover"
+)
 
 // IdentityCheckerProxy used as proxy interface to solve
 // cyclic dependency relate with MD5Checker
@@ -16,6 +20,17 @@ type RegistryProxy struct {
 	ExecTime     int
 	MD5Sum       string
 	ProtocolType string
+	Codec        string
+	TLS          *discover.PluginTLS
+	Readiness    *discover.PluginReadiness
+	Limits       *discover.PluginLimits
+
+	Env            map[string]string
+	EnvPassthrough []string
+	EnvFromFile    map[string]string
+	Secrets        map[string]string
+	ClearEnv       bool
+	Workdir        string
 }
 
 // Plugin as main observable item
@@ -3,6 +3,7 @@ package host
 import (
 	"context"
 	"fmt"
+	"path"
 
 	"github.com/quadroops/goplugin/pkg/discover"
 	"github.com/quadroops/goplugin/pkg/errs"
@@ -51,12 +52,22 @@ func (b *Builder) Setup() Plugins {
 				pluginInfo, exist := b.Config.Plugins[plugin]
 				if exist {
 					hostPlugins[PluginName(plugin)] = &Registry{
-						ExecFile:     pluginInfo.ExecFile,
-						ExecArgs:     pluginInfo.ExecArgs,
-						ExecPath:     pluginInfo.Exec,
-						ExecTime:     pluginInfo.ExecTime,
-						MD5Sum:       pluginInfo.MD5,
-						ProtocolType: pluginInfo.ProtocolType,
+						ExecFile:       pluginInfo.ExecFile,
+						ExecArgs:       pluginInfo.ExecArgs,
+						ExecPath:       pluginInfo.Exec,
+						ExecTime:       pluginInfo.ExecTime,
+						MD5Sum:         pluginInfo.MD5,
+						ProtocolType:   pluginInfo.ProtocolType,
+						Codec:          pluginInfo.Codec,
+						TLS:            pluginInfo.TLS,
+						Readiness:      pluginInfo.Readiness,
+						Limits:         pluginInfo.Limits,
+						Env:            pluginInfo.Env,
+						EnvPassthrough: pluginInfo.EnvPassthrough,
+						EnvFromFile:    pluginInfo.EnvFromFile,
+						Secrets:        pluginInfo.Secrets,
+						ClearEnv:       pluginInfo.ClearEnv,
+						Workdir:        pluginInfo.Workdir,
 					}
 				}
 			}
@@ -65,6 +76,71 @@ func (b *Builder) Setup() Plugins {
 	return hostPlugins
 }
 
+// CanCall used to check if a plugin allowed to call other plugin, both plugins
+// must be registered to the host and the callee must be listed in host's calls
+func (b *Builder) CanCall(from, to string) bool {
+	h, exist := b.hostConfig()
+	if !exist {
+		return false
+	}
+
+	return contains(h.Plugins, from) && contains(h.Plugins, to) && contains(h.Calls[from], to)
+}
+
+// CanPublish used to check if a plugin allowed to publish an event on given topic
+func (b *Builder) CanPublish(plugin, topic string) bool {
+	h, exist := b.hostConfig()
+	if !exist {
+		return false
+	}
+
+	return contains(h.Plugins, plugin) && matchTopic(h.Events.Publish[plugin], topic)
+}
+
+// CanSubscribe used to check if a plugin allowed to subscribe given topic, topic
+// may be a pattern, it must be the same as or covered by one of allowed patterns
+func (b *Builder) CanSubscribe(plugin, topic string) bool {
+	h, exist := b.hostConfig()
+	if !exist {
+		return false
+	}
+
+	return contains(h.Plugins, plugin) && matchTopic(h.Events.Subscribe[plugin], topic)
+}
+
+func (b *Builder) hostConfig() (discover.PluginHost, bool) {
+	if b.Config == nil {
+		return discover.PluginHost{}, false
+	}
+
+	h, exist := b.Config.Hosts[b.Hostname]
+	return h, exist
+}
+
+func matchTopic(patterns []string, topic string) bool {
+	for _, pattern := range patterns {
+		if pattern == topic {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Install used to validate given available plugins, and return a Host
 // a mapper between host and their plugins
 func (b *Builder) Install(plugins Plugins) (Host, error) {
@@ -78,12 +154,22 @@ func (b *Builder) Install(plugins Plugins) (Host, error) {
 	source := func(_ context.Context, next chan<- rxgo.Item) {
 		for name, p := range plugins {
 			flowInstallRegistry := flow.RegistryProxy{
-				ExecFile:     p.ExecFile,
-				ExecArgs:     p.ExecArgs,
-				ExecPath:     p.ExecPath,
-				ExecTime:     p.ExecTime,
-				MD5Sum:       p.MD5Sum,
-				ProtocolType: p.ProtocolType,
+				ExecFile:       p.ExecFile,
+				ExecArgs:       p.ExecArgs,
+				ExecPath:       p.ExecPath,
+				ExecTime:       p.ExecTime,
+				MD5Sum:         p.MD5Sum,
+				ProtocolType:   p.ProtocolType,
+				Codec:          p.Codec,
+				TLS:            p.TLS,
+				Readiness:      p.Readiness,
+				Limits:         p.Limits,
+				Env:            p.Env,
+				EnvPassthrough: p.EnvPassthrough,
+				EnvFromFile:    p.EnvFromFile,
+				Secrets:        p.Secrets,
+				ClearEnv:       p.ClearEnv,
+				Workdir:        p.Workdir,
 			}
 
 			flowPlugin := flow.Plugin{
@@ -103,12 +189,22 @@ func (b *Builder) Install(plugins Plugins) (Host, error) {
 			plugin, ok := v.(flow.Plugin)
 			if ok {
 				rebuildlugins[PluginName(plugin.Name)] = &Registry{
-					ExecFile:     plugin.Registry.ExecFile,
-					ExecArgs:     plugin.Registry.ExecArgs,
-					ExecPath:     plugin.Registry.ExecPath,
-					ExecTime:     plugin.Registry.ExecTime,
-					MD5Sum:       plugin.Registry.MD5Sum,
-					ProtocolType: plugin.Registry.ProtocolType,
+					ExecFile:       plugin.Registry.ExecFile,
+					ExecArgs:       plugin.Registry.ExecArgs,
+					ExecPath:       plugin.Registry.ExecPath,
+					ExecTime:       plugin.Registry.ExecTime,
+					MD5Sum:         plugin.Registry.MD5Sum,
+					ProtocolType:   plugin.Registry.ProtocolType,
+					Codec:          plugin.Registry.Codec,
+					TLS:            plugin.Registry.TLS,
+					Readiness:      plugin.Registry.Readiness,
+					Limits:         plugin.Registry.Limits,
+					Env:            plugin.Registry.Env,
+					EnvPassthrough: plugin.Registry.EnvPassthrough,
+					EnvFromFile:    plugin.Registry.EnvFromFile,
+					Secrets:        plugin.Registry.Secrets,
+					ClearEnv:       plugin.Registry.ClearEnv,
+					Workdir:        plugin.Registry.Workdir,
 				}
 			}
 		})
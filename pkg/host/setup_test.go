@@ -70,6 +70,15 @@ const (
 
 		[hosts.host_1]
 		plugins = ["name_1", "name_2", "name_3"]		
+
+			[hosts.host_1.calls]
+			name_1 = ["name_2", "name_4"]
+
+			[hosts.host_1.events.publish]
+			name_1 = ["order.*"]
+
+			[hosts.host_1.events.subscribe]
+			name_2 = ["order.created"]
 		
 		[hosts.host_2]
 		plugins = []		
@@ -183,3 +192,36 @@ func TestInstallNoPlugins(t *testing.T) {
 	assert.True(t, errors.Is(err, errs.ErrNoPlugins))
 	md5Drv.AssertCalled(t, "Parse", "./tmp/test")
 }
+
+func TestCanCall(t *testing.T) {
+	parser := driver.NewTomlParser()
+	conf, err := parser.Parse([]byte(tomlContent))
+	assert.NoError(t, err)
+
+	md5 := new(mocks.MD5Checker)
+	h := host.New("host_1", conf, md5)
+	assert.True(t, h.CanCall("name_1", "name_2"))
+	assert.False(t, h.CanCall("name_2", "name_1"))
+	assert.False(t, h.CanCall("name_1", "name_3"))
+
+	// name_4 is allowed but not registered to the host
+	assert.False(t, h.CanCall("name_1", "name_4"))
+	assert.False(t, host.New("host_3", conf, md5).CanCall("name_1", "name_2"))
+	assert.False(t, host.New("host_1", nil, md5).CanCall("name_1", "name_2"))
+}
+
+func TestCanPublishSubscribe(t *testing.T) {
+	parser := driver.NewTomlParser()
+	conf, err := parser.Parse([]byte(tomlContent))
+	assert.NoError(t, err)
+
+	md5 := new(mocks.MD5Checker)
+	h := host.New("host_1", conf, md5)
+	assert.True(t, h.CanPublish("name_1", "order.created"))
+	assert.False(t, h.CanPublish("name_1", "user.created"))
+	assert.False(t, h.CanPublish("name_2", "order.created"))
+
+	assert.True(t, h.CanSubscribe("name_2", "order.created"))
+	assert.False(t, h.CanSubscribe("name_2", "order.*"))
+	assert.False(t, h.CanSubscribe("name_1", "order.created"))
+}
@@ -1,5 +1,7 @@
 package host
 
+import "github.com/quadroops/goplugin/pkg/discover"
+
 // IdentityChecker used to check identity for security purpose
 // By default, we will use md5 file checker to check plugin's md5 value
 type IdentityChecker interface {
@@ -20,6 +22,17 @@ type Registry struct {
 	ExecTime     int
 	MD5Sum       string
 	ProtocolType string
+	Codec        string
+	TLS          *discover.PluginTLS
+	Readiness    *discover.PluginReadiness
+	Limits       *discover.PluginLimits
+
+	Env            map[string]string
+	EnvPassthrough []string
+	EnvFromFile    map[string]string
+	Secrets        map[string]string
+	ClearEnv       bool
+	Workdir        string
 }
 
 // Plugins is a mapper a plugin and their metadata
@@ -0,0 +1,140 @@
+package driver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+const (
+	// EnvTLSCACert used as environment variable's name to pass CA certificate to plugin
+	EnvTLSCACert = "GOPLUGIN_TLS_CA_CERT"
+
+	// EnvTLSServerCert used as environment variable's name to pass plugin's certificate
+	EnvTLSServerCert = "GOPLUGIN_TLS_SERVER_CERT"
+
+	// EnvTLSServerKey used as environment variable's name to pass plugin's private key
+	EnvTLSServerKey = "GOPLUGIN_TLS_SERVER_KEY"
+
+	// CredentialsValidity used as validity period of generated certificates
+	CredentialsValidity = 365 * 24 * time.Hour
+)
+
+// GenerateCredentials used to generate throwaway CA, plugin's (server) and host's (client)
+// key pairs signed by the CA.  All generated materials are PEM encoded
+func GenerateCredentials(name string) (*process.Credentials, error) {
+	notBefore := time.Now().Add(-1 * time.Minute)
+	notAfter := notBefore.Add(CredentialsValidity)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginCredentials, err)
+	}
+
+	caTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("goplugin-ca-%s", name)},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	caCert, caPEM, _, err := signCertificate(caTemplate, nil, caKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, serverPEM, serverKeyPEM, err := signCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, caKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, clientPEM, clientKeyPEM, err := signCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: fmt.Sprintf("goplugin-host-%s", name)},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &process.Credentials{
+		CACert:     caPEM,
+		ServerCert: serverPEM,
+		ServerKey:  serverKeyPEM,
+		ClientCert: clientPEM,
+		ClientKey:  clientKeyPEM,
+	}, nil
+}
+
+// credentialsEnv used to build environment variables used to pass
+// plugin's materials
+func credentialsEnv(creds *process.Credentials) []string {
+	return []string{
+		fmt.Sprintf("%s=%s", EnvTLSCACert, creds.CACert),
+		fmt.Sprintf("%s=%s", EnvTLSServerCert, creds.ServerCert),
+		fmt.Sprintf("%s=%s", EnvTLSServerKey, creds.ServerKey),
+	}
+}
+
+// signCertificate used to sign given template using parent's key, if key is nil
+// a new key will be generated.  Return parsed certificate, PEM encoded certificate
+// and PEM encoded private key
+func signCertificate(template, parent *x509.Certificate, parentKey, key *ecdsa.PrivateKey) (*x509.Certificate, []byte, []byte, error) {
+	var err error
+	if key == nil {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: %q", errs.ErrPluginCredentials, err)
+		}
+	}
+
+	template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %q", errs.ErrPluginCredentials, err)
+	}
+
+	if parent == nil {
+		parent = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %q", errs.ErrPluginCredentials, err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %q", errs.ErrPluginCredentials, err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %q", errs.ErrPluginCredentials, err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return cert, certPEM, keyPEM, nil
+}
@@ -0,0 +1,53 @@
+package driver_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCredentials(t *testing.T) {
+	creds, err := driver.GenerateCredentials("test")
+	assert.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(creds.CACert))
+
+	server, err := tls.X509KeyPair(creds.ServerCert, creds.ServerKey)
+	assert.NoError(t, err)
+
+	serverCert, err := x509.ParseCertificate(server.Certificate[0])
+	assert.NoError(t, err)
+
+	_, err = serverCert.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		DNSName:   "localhost",
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	assert.NoError(t, err)
+
+	client, err := tls.X509KeyPair(creds.ClientCert, creds.ClientKey)
+	assert.NoError(t, err)
+
+	clientCert, err := x509.ParseCertificate(client.Certificate[0])
+	assert.NoError(t, err)
+
+	_, err = clientCert.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+}
+
+func TestGenerateCredentialsUnique(t *testing.T) {
+	creds1, err := driver.GenerateCredentials("test")
+	assert.NoError(t, err)
+
+	creds2, err := driver.GenerateCredentials("test")
+	assert.NoError(t, err)
+	assert.NotEqual(t, creds1.CACert, creds2.CACert)
+	assert.NotEqual(t, creds1.ServerCert, creds2.ServerCert)
+}
@@ -0,0 +1,139 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+const (
+	// SecretFile used as secret's scheme to read its value from a file
+	SecretFile = "file"
+
+	// SecretEnv used as secret's scheme to read its value from host's environment variable,
+	// useful to pass a single host's variable to a plugin using cleared environment
+	SecretEnv = "env"
+)
+
+// WithSecretResolver used to register a resolver for plugin's secret references using
+// given scheme, built-in file and env schemes can be overridden
+func WithSecretResolver(scheme string, resolver process.SecretResolver) SubProcessOption {
+	return func(r *runner) {
+		if r.resolvers == nil {
+			r.resolvers = make(map[string]process.SecretResolver)
+		}
+
+		r.resolvers[scheme] = resolver
+	}
+}
+
+func resolveFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func resolveEnv(name string) (string, error) {
+	value, exist := os.LookupEnv(name)
+	if !exist {
+		return "", fmt.Errorf("variable %q is not defined", name)
+	}
+
+	return value, nil
+}
+
+// resolveSecret used to resolve a "<scheme>:<ref>" secret reference using registered
+// resolvers, resolver's error should never contain secret's value
+func resolveSecret(ref string, resolvers map[string]process.SecretResolver) (string, error) {
+	i := strings.Index(ref, ":")
+	if i < 1 {
+		return "", fmt.Errorf("invalid secret reference")
+	}
+
+	scheme, value := ref[:i], ref[i+1:]
+	if resolver, exist := resolvers[scheme]; exist {
+		return resolver(value)
+	}
+
+	switch scheme {
+	case SecretFile:
+		return resolveFile(value)
+	case SecretEnv:
+		return resolveEnv(value)
+	}
+
+	return "", fmt.Errorf("unknown secret scheme %q", scheme)
+}
+
+// buildEnv used to build plugin's process environment, inherited variables first and
+// followed by plugin's own variables, so the later ones will be used by the process
+func buildEnv(env process.Environment, resolvers map[string]process.SecretResolver) ([]string, error) {
+	var environ []string
+	if env.Clear {
+		for _, name := range env.Passthrough {
+			if value, exist := os.LookupEnv(name); exist {
+				environ = append(environ, fmt.Sprintf("%s=%s", name, value))
+			}
+		}
+	} else {
+		environ = os.Environ()
+	}
+
+	for _, name := range sortedKeys(env.Env) {
+		if !validEnvName(name) {
+			return nil, fmt.Errorf("%w: invalid variable name %q", errs.ErrPluginEnv, name)
+		}
+
+		environ = append(environ, fmt.Sprintf("%s=%s", name, env.Env[name]))
+	}
+
+	for _, name := range sortedKeys(env.Files) {
+		if !validEnvName(name) {
+			return nil, fmt.Errorf("%w: invalid variable name %q", errs.ErrPluginEnv, name)
+		}
+
+		value, err := resolveFile(env.Files[name])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %q", errs.ErrPluginSecret, name, err)
+		}
+
+		environ = append(environ, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	for _, name := range sortedKeys(env.Secrets) {
+		if !validEnvName(name) {
+			return nil, fmt.Errorf("%w: invalid variable name %q", errs.ErrPluginEnv, name)
+		}
+
+		value, err := resolveSecret(env.Secrets[name], resolvers)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %q", errs.ErrPluginSecret, name, err)
+		}
+
+		environ = append(environ, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	return environ, nil
+}
+
+func validEnvName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "=\x00")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package driver_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSubProcessEnvironment(t *testing.T) {
+	dir := tempDir(t)
+	token := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(token, []byte("file-secret\n"), 0600))
+
+	os.Setenv("GOPLUGIN_TEST_HOST", "host-value")
+	os.Setenv("GOPLUGIN_TEST_HIDDEN", "hidden-value")
+	defer os.Unsetenv("GOPLUGIN_TEST_HOST")
+	defer os.Unsetenv("GOPLUGIN_TEST_HIDDEN")
+
+	sub := driver.NewSubProcess(driver.WithSecretResolver("vault", func(ref string) (string, error) {
+		return "vault-" + ref, nil
+	})).(process.EnvRunner)
+
+	env := process.Environment{
+		Env:         map[string]string{"FOO": "bar"},
+		Passthrough: []string{"GOPLUGIN_TEST_HOST"},
+		Files:       map[string]string{"TOKEN": token},
+		Secrets:     map[string]string{"SECRET": "vault:db", "RENAMED": "env:GOPLUGIN_TEST_HIDDEN"},
+		Clear:       true,
+		Workdir:     dir,
+	}
+
+	script := `echo "$FOO|$TOKEN|$SECRET|$RENAMED|$GOPLUGIN_TEST_HOST|$GOPLUGIN_TEST_HIDDEN|$(pwd)"`
+	ch, err := sub.RunEnv(env, 0, "test", "sh", 1, "-c", script)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	exit := waitExit(t, plugin)
+	assert.Equal(t, 0, exit.Code)
+
+	expected := strings.Join([]string{"bar", "file-secret", "vault-db", "hidden-value", "host-value", "", dir}, "|")
+	assert.Equal(t, expected, strings.TrimSpace(plugin.Stdout.String()))
+}
+
+func TestRunSubProcessInheritEnvironment(t *testing.T) {
+	os.Setenv("GOPLUGIN_TEST_HOST", "host-value")
+	defer os.Unsetenv("GOPLUGIN_TEST_HOST")
+
+	sub := driver.NewSubProcess().(process.EnvRunner)
+	env := process.Environment{Env: map[string]string{"FOO": "bar"}}
+
+	ch, err := sub.RunEnv(env, 0, "test", "sh", 1, "-c", `echo "$FOO|$GOPLUGIN_TEST_HOST"`)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	waitExit(t, plugin)
+	assert.Equal(t, "bar|host-value", strings.TrimSpace(plugin.Stdout.String()))
+}
+
+func TestRunSubProcessSecretError(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithSecretResolver("vault", func(ref string) (string, error) {
+		return "", errors.New("permission denied")
+	})).(process.EnvRunner)
+
+	ch, err := sub.RunEnv(process.Environment{
+		Secrets: map[string]string{"SECRET": "vault:db"},
+	}, 0, "test", "sh", 1, "-c", "exit 0")
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginSecret))
+	assert.Contains(t, err.Error(), "SECRET")
+	assert.Nil(t, ch)
+
+	_, err = sub.RunEnv(process.Environment{
+		Secrets: map[string]string{"SECRET": "unknown:db"},
+	}, 0, "test", "sh", 1, "-c", "exit 0")
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginSecret))
+}
+
+func TestRunSubProcessInvalidEnvName(t *testing.T) {
+	sub := driver.NewSubProcess().(process.EnvRunner)
+	ch, err := sub.RunEnv(process.Environment{
+		Env: map[string]string{"FOO=BAR": "baz"},
+	}, 0, "test", "sh", 1, "-c", "exit 0")
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginEnv))
+	assert.Nil(t, ch)
+}
+
+func TestRunPipeProcessEnvironment(t *testing.T) {
+	dir := tempDir(t)
+	sub := driver.NewPipeProcess().(process.EnvRunner)
+
+	ch, err := sub.RunEnv(process.Environment{
+		Env:     map[string]string{"FOO": "bar"},
+		Clear:   true,
+		Workdir: dir,
+	}, 0, "test", "sh", 0, "-c", `echo "$FOO|$HOME|$(pwd)"; sleep 5`)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	defer plugin.Kill()
+
+	line := make([]byte, 256)
+	n, err := plugin.Pipe.Read(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar||"+dir, strings.TrimSpace(string(line[:n])))
+}
@@ -0,0 +1,41 @@
+package driver
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/quadroops/goplugin/internal/utils"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+// ExitStderrTail used as maximum length of plugin's stderr sent on its exit status
+const ExitStderrTail = 4096
+
+// newExit used to build plugin's exit status from its waited command
+func newExit(name string, cmd *exec.Cmd, started time.Time, stderr *utils.Buffer) process.Exit {
+	exit := process.Exit{
+		Name:    name,
+		ID:      process.ID(cmd.Process.Pid),
+		Code:    -1,
+		Runtime: time.Since(started),
+		Stderr:  tail(stderr.String(), ExitStderrTail),
+	}
+
+	if state := cmd.ProcessState; state != nil {
+		exit.Code = state.ExitCode()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			exit.Signal = status.Signal().String()
+		}
+	}
+
+	return exit
+}
+
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[len(s)-n:]
+}
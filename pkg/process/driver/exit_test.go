@@ -0,0 +1,68 @@
+package driver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/process"
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitExit(t *testing.T, plugin process.Plugin) process.Exit {
+	select {
+	case exit := <-plugin.Exited:
+		return exit
+	case <-time.After(5 * time.Second):
+		t.Fatal("exit status not received")
+	}
+
+	return process.Exit{}
+}
+
+func TestSubProcessExitCode(t *testing.T) {
+	sub := driver.NewSubProcess()
+	ch, err := sub.Run(0, "test", "sh", 1, "-c", "echo boom >&2; exit 3")
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	exit := waitExit(t, plugin)
+	assert.Equal(t, "test", exit.Name)
+	assert.Equal(t, plugin.ID, exit.ID)
+	assert.Equal(t, 3, exit.Code)
+	assert.Empty(t, exit.Signal)
+	assert.Equal(t, "boom\n", exit.Stderr)
+	assert.True(t, exit.Runtime > 0)
+}
+
+func TestSubProcessExitSignal(t *testing.T) {
+	sub := driver.NewSubProcess()
+	ch, err := sub.Run(0, "test", "sh", 1, "-c", "sleep 30")
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	plugin.Kill()
+
+	exit := waitExit(t, plugin)
+	assert.Equal(t, -1, exit.Code)
+	assert.Equal(t, "killed", exit.Signal)
+}
+
+func TestSubProcessExitStderrTail(t *testing.T) {
+	sub := driver.NewSubProcess()
+	ch, err := sub.Run(0, "test", "sh", 1, "-c", "head -c 10000 /dev/zero | tr '\\0' a >&2; printf end >&2")
+	assert.NoError(t, err)
+
+	exit := waitExit(t, <-ch)
+	assert.Len(t, exit.Stderr, driver.ExitStderrTail)
+	assert.Equal(t, "end", exit.Stderr[len(exit.Stderr)-3:])
+}
+
+func TestPipeProcessExitCode(t *testing.T) {
+	sub := driver.NewPipeProcess()
+	ch, err := sub.Run(0, "test", "sh", 0, "-c", "exit 2")
+	assert.NoError(t, err)
+
+	exit := waitExit(t, <-ch)
+	assert.Equal(t, 2, exit.Code)
+}
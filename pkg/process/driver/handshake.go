@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+const (
+	// EnvHandshakeCookie used as environment variable's name to pass handshake's cookie,
+	// plugin must send back the same cookie on its handshake line
+	EnvHandshakeCookie = "GOPLUGIN_HANDSHAKE_COOKIE"
+
+	// EnvProtocolVersions used as environment variable's name to pass host's supported
+	// protocol versions, separated by comma
+	EnvProtocolVersions = "GOPLUGIN_PROTOCOL_VERSIONS"
+
+	// DefaultHandshakeTimeout used when plugin's exec time is not defined
+	DefaultHandshakeTimeout = 10 * time.Second
+
+	// maxHandshakeLength used to limit handshake line's length
+	maxHandshakeLength = 64 * 1024
+)
+
+// HandshakeOptions used to configure launch handshake.  Versions is a list of
+// host's supported protocol versions, Timeout used as waiting time for handshake
+// line if plugin's exec time is not defined
+type HandshakeOptions struct {
+	Versions []int
+	Timeout  time.Duration
+}
+
+// handshakeWriter used to catch the first line of plugin's stdout, all
+// next lines will be written to the real output
+type handshakeWriter struct {
+	mutex sync.Mutex
+	out   io.Writer
+	line  bytes.Buffer
+	done  bool
+	ch    chan string
+}
+
+func newHandshakeWriter(out io.Writer) *handshakeWriter {
+	return &handshakeWriter{
+		out: out,
+		ch:  make(chan string, 1),
+	}
+}
+
+func (h *handshakeWriter) Write(p []byte) (int, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.done {
+		return h.out.Write(p)
+	}
+
+	i := bytes.IndexByte(p, '\n')
+	if i < 0 {
+		h.line.Write(p)
+		if h.line.Len() > maxHandshakeLength {
+			h.finish()
+		}
+
+		return len(p), nil
+	}
+
+	h.line.Write(p[:i])
+	h.finish()
+
+	if rest := p[i+1:]; len(rest) > 0 {
+		if _, err := h.out.Write(rest); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (h *handshakeWriter) finish() {
+	h.done = true
+	h.ch <- strings.TrimSpace(h.line.String())
+}
+
+func newCookie() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: %q", errs.ErrPluginHandshake, err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func handshakeEnv(cookie string, versions []int) []string {
+	var strVersions []string
+	for _, v := range versions {
+		strVersions = append(strVersions, strconv.Itoa(v))
+	}
+
+	return []string{
+		fmt.Sprintf("%s=%s", EnvHandshakeCookie, cookie),
+		fmt.Sprintf("%s=%s", EnvProtocolVersions, strings.Join(strVersions, ",")),
+	}
+}
+
+// ParseHandshake used to parse and validate plugin's handshake line, given
+// cookie and supported versions must match with plugin's handshake
+func ParseHandshake(line, cookie string, versions []int) (*process.Handshake, error) {
+	if line == "" {
+		return nil, fmt.Errorf("%w: empty handshake line", errs.ErrPluginHandshake)
+	}
+
+	var handshake process.Handshake
+	err := json.Unmarshal([]byte(line), &handshake)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid handshake line %q", errs.ErrPluginHandshake, line)
+	}
+
+	if handshake.Cookie != cookie {
+		return nil, fmt.Errorf("%w: cookie mismatch", errs.ErrPluginHandshake)
+	}
+
+	var supported bool
+	for _, v := range versions {
+		if v == handshake.Version {
+			supported = true
+			break
+		}
+	}
+
+	if !supported {
+		return nil, fmt.Errorf("%w: unsupported protocol version %d, host supports %v", errs.ErrPluginHandshake, handshake.Version, versions)
+	}
+
+	if handshake.Protocol == "" {
+		return nil, fmt.Errorf("%w: missing protocol", errs.ErrPluginHandshake)
+	}
+
+	return &handshake, nil
+}
@@ -0,0 +1,41 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHandshakeSuccess(t *testing.T) {
+	line := `{"cookie":"abc","protocol":"grpc","version":1,"network":"tcp","address":"127.0.0.1:8081","capabilities":["stream"]}`
+	handshake, err := driver.ParseHandshake(line, "abc", []int{1})
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc", handshake.Protocol)
+	assert.Equal(t, "tcp", handshake.Network)
+	assert.Equal(t, "127.0.0.1:8081", handshake.Address)
+	assert.Equal(t, []string{"stream"}, handshake.Capabilities)
+}
+
+func TestParseHandshakeFailed(t *testing.T) {
+	testCases := []struct {
+		name string
+		line string
+	}{
+		{"empty", ""},
+		{"invalid json", "listening on 8081"},
+		{"cookie mismatch", `{"cookie":"xyz","protocol":"grpc","version":1}`},
+		{"unsupported version", `{"cookie":"abc","protocol":"grpc","version":3}`},
+		{"missing protocol", `{"cookie":"abc","version":1}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := driver.ParseHandshake(tc.line, "abc", []int{1, 2})
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, errs.ErrPluginHandshake))
+		})
+	}
+}
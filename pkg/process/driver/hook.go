@@ -0,0 +1,47 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+// LaunchHook used to prepare resources for each plugin's launch such as callback
+// servers.  Returned env will be passed to the plugin, and release will be called
+// after the plugin exited or failed to start
+type LaunchHook func(name string) (env []string, release func(), err error)
+
+// WithLaunchHook used to register a hook called each time a plugin launched
+func WithLaunchHook(hook LaunchHook) SubProcessOption {
+	return func(r *runner) {
+		r.hooks = append(r.hooks, hook)
+	}
+}
+
+// runHooks used to call all registered hooks, if one of the hooks failed, all
+// acquired resources will be released
+func runHooks(hooks []LaunchHook, name string) ([]string, func(), error) {
+	var env []string
+	var releases []func()
+
+	release := func() {
+		for _, r := range releases {
+			if r != nil {
+				r()
+			}
+		}
+	}
+
+	for _, hook := range hooks {
+		hookEnv, hookRelease, err := hook(name)
+		if err != nil {
+			release()
+			return nil, nil, fmt.Errorf("%w: %q", errs.ErrPluginCannotStart, err)
+		}
+
+		env = append(env, hookEnv...)
+		releases = append(releases, hookRelease)
+	}
+
+	return env, release, nil
+}
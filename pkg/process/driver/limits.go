@@ -0,0 +1,166 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+// CPUPeriod used as cgroup's cpu.max period in microseconds
+const CPUPeriod = 100000
+
+// shim's environment variables, plugin having limits will be started by re-executing
+// host's executable with these variables.  Host's main calling RunShim applies the
+// limits to its own process and then executes the plugin
+const (
+	shimExec    = "GOPLUGIN_SHIM_EXEC"
+	shimStatus  = "GOPLUGIN_SHIM_STATUS"
+	shimRlimits = "GOPLUGIN_SHIM_RLIMITS"
+	shimCgroup  = "GOPLUGIN_SHIM_CGROUP"
+)
+
+// WithCgroup used to put each plugin having memory, processes or CPU limits in its own
+// cgroup v2 under given directory.  The directory must be a writable cgroup v2 delegated
+// to host's user and must not contain any processes, so its controllers can be enabled
+// for plugin's cgroups.  Plugin's cgroup will be removed after the plugin exited.
+// Plugins join their cgroups through the shim, so host's main must call RunShim
+func WithCgroup(dir string) SubProcessOption {
+	return func(r *runner) {
+		r.cgroupDir = dir
+	}
+}
+
+// cgroup used to store plugin's cgroup path, plugin's process will join the cgroup
+// before executed
+type cgroup struct {
+	path string
+}
+
+// newCgroup used to create plugin's cgroup and write its limits, nil will be returned
+// if there is no cgroup's directory or limits
+func newCgroup(parent, name string, limits *process.Limits) (*cgroup, error) {
+	if parent == "" || limits == nil {
+		return nil, nil
+	}
+
+	files := make(map[string]string)
+	var controllers []string
+	if limits.Memory > 0 {
+		files["memory.max"] = strconv.FormatInt(limits.Memory, 10)
+		controllers = append(controllers, "+memory")
+	}
+
+	if limits.CPU > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d %d", int64(limits.CPU*CPUPeriod), CPUPeriod)
+		controllers = append(controllers, "+cpu")
+	}
+
+	if limits.Processes > 0 {
+		files["pids.max"] = strconv.FormatInt(limits.Processes, 10)
+		controllers = append(controllers, "+pids")
+	}
+
+	if len(controllers) < 1 {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("%w: %s is not a cgroup v2 directory", errs.ErrPluginLimits, parent)
+	}
+
+	// controllers must be enabled by the parent before used by its children
+	err := ioutil.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginLimits, err)
+	}
+
+	// restarted plugin may reuse its previous cgroup
+	path := filepath.Join(parent, name)
+	if err = os.Mkdir(path, 0755); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("%w: %q", errs.ErrPluginLimits, err)
+	}
+
+	for file, value := range files {
+		err = ioutil.WriteFile(filepath.Join(path, file), []byte(value), 0)
+		if err != nil {
+			os.Remove(path)
+			return nil, fmt.Errorf("%w: %q", errs.ErrPluginLimits, err)
+		}
+	}
+
+	return &cgroup{path: path}, nil
+}
+
+// remove used to remove plugin's cgroup, it can only be removed after
+// all plugin's processes exited
+func (c *cgroup) remove() {
+	if c != nil {
+		os.Remove(c.path)
+	}
+}
+
+// startLimited used to start the command with plugin's limits applied before the plugin
+// executed, so the plugin never runs without them.  Shim's failure will be reported
+// through a status pipe, which will be closed without any message once the plugin executed
+func startLimited(cmd *exec.Cmd, cg *cgroup, limits *process.Limits) error {
+	env, err := shimEnv(cg, limits)
+	if err != nil {
+		return err
+	}
+
+	if len(env) < 1 {
+		return cmd.Start()
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrPluginLimits, err)
+	}
+
+	status, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("%w: %q", errs.ErrPluginLimits, err)
+	}
+
+	defer status.Close()
+
+	// extra files started from fd 3 on the shim
+	cmd.ExtraFiles = append(cmd.ExtraFiles, w)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Env = append(cmd.Env,
+		fmt.Sprintf("%s=%s", shimExec, cmd.Path),
+		fmt.Sprintf("%s=%d", shimStatus, len(cmd.ExtraFiles)+2),
+	)
+
+	cmd.Path = self
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	msg, _ := ioutil.ReadAll(status)
+	if len(msg) > 0 {
+		cmd.Wait()
+		return fmt.Errorf("%w: %s", errs.ErrPluginLimits, msg)
+	}
+
+	return nil
+}
+
+// startError used to wrap command's start error, limits errors returned as is
+func startError(err error) error {
+	if errors.Is(err, errs.ErrPluginLimits) {
+		return err
+	}
+
+	return fmt.Errorf("%q: %w", err.Error(), errs.ErrPluginCannotStart)
+}
@@ -0,0 +1,213 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+// rlimitNproc used as RLIMIT_NPROC which is not exported by syscall package
+const rlimitNproc = 0x6
+
+// shim's exit code when the limits cannot be applied or the plugin cannot be executed
+const shimExitCode = 126
+
+// shimReady used to mark host's executable calling RunShim, plugins having limits
+// will only be started through the shim after that
+var shimReady int32
+
+// RunShim used to run as plugin's shim when host's executable re-executed by the runner
+// to apply plugin's limits, the plugin will replace the shim's process.  Host must call it
+// at the beginning of its main when its plugins have any resource limits, otherwise those
+// plugins will not be started.  It returns immediately when the process is not a shim
+func RunShim() {
+	path, fd, ok := shimTarget()
+	if !ok {
+		atomic.StoreInt32(&shimReady, 1)
+		return
+	}
+
+	// the pipe will be closed once the plugin executed
+	syscall.CloseOnExec(fd)
+	status := os.NewFile(uintptr(fd), "status")
+
+	err := shim(path)
+	status.WriteString(err.Error())
+	os.Exit(shimExitCode)
+}
+
+// shimTarget used to get plugin's path and shim's status pipe, the process only taken
+// over when its shim's variables are exactly those set by the runner and its status
+// is an open pipe, so inherited variables will not turn the host into a shim
+func shimTarget() (string, int, bool) {
+	path := os.Getenv(shimExec)
+	if path == "" {
+		return "", 0, false
+	}
+
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, "GOPLUGIN_SHIM_") && !isShimEnv(env) {
+			return "", 0, false
+		}
+	}
+
+	fd, err := strconv.Atoi(os.Getenv(shimStatus))
+	if err != nil || fd < 3 {
+		return "", 0, false
+	}
+
+	var stat syscall.Stat_t
+	if err = syscall.Fstat(fd, &stat); err != nil || stat.Mode&syscall.S_IFMT != syscall.S_IFIFO {
+		return "", 0, false
+	}
+
+	return path, fd, true
+}
+
+// shim used to join plugin's cgroup, set plugin's rlimits and execute the plugin,
+// it only returns when failed
+func shim(path string) error {
+	if cgroup := os.Getenv(shimCgroup); cgroup != "" {
+		// cgroup.procs must not be created when the directory is not a cgroup
+		procs, err := os.OpenFile(filepath.Join(cgroup, "cgroup.procs"), os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+
+		_, err = procs.WriteString(strconv.Itoa(os.Getpid()))
+		procs.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	var env []string
+	for _, value := range os.Environ() {
+		if !isShimEnv(value) {
+			env = append(env, value)
+		}
+	}
+
+	resources, err := parseRlimits(os.Getenv(shimRlimits))
+	if err != nil {
+		return err
+	}
+
+	// both soft and hard limits will be set so the plugin can't raise them back
+	for resource, value := range resources {
+		err = syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value})
+		if err != nil {
+			return fmt.Errorf("resource %d: %q", resource, err)
+		}
+	}
+
+	return syscall.Exec(path, os.Args, env)
+}
+
+// isShimEnv used to check shim's own environment variables, which should not be
+// passed to the plugin
+func isShimEnv(env string) bool {
+	for _, key := range []string{shimExec, shimStatus, shimRlimits, shimCgroup} {
+		if strings.HasPrefix(env, key+"=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rlimits used to map plugin's limits into process's rlimits
+func rlimits(limits *process.Limits) map[int]uint64 {
+	resources := make(map[int]uint64)
+	if limits.Memory > 0 {
+		resources[syscall.RLIMIT_AS] = uint64(limits.Memory)
+	}
+
+	if limits.CPUTime > 0 {
+		resources[syscall.RLIMIT_CPU] = uint64(limits.CPUTime)
+	}
+
+	if limits.OpenFiles > 0 {
+		resources[syscall.RLIMIT_NOFILE] = uint64(limits.OpenFiles)
+	}
+
+	if limits.Processes > 0 {
+		resources[rlimitNproc] = uint64(limits.Processes)
+	}
+
+	if limits.CoreSize != nil && *limits.CoreSize >= 0 {
+		resources[syscall.RLIMIT_CORE] = uint64(*limits.CoreSize)
+	}
+
+	return resources
+}
+
+// formatRlimits used to encode rlimits as shim's variable, such as 4=0,7=64
+func formatRlimits(resources map[int]uint64) string {
+	var values []string
+	for resource, value := range resources {
+		values = append(values, fmt.Sprintf("%d=%d", resource, value))
+	}
+
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// parseRlimits used to decode shim's rlimits variable
+func parseRlimits(value string) (map[int]uint64, error) {
+	resources := make(map[int]uint64)
+	if value == "" {
+		return resources, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rlimit: %s", pair)
+		}
+
+		resource, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		limit, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		resources[resource] = limit
+	}
+
+	return resources, nil
+}
+
+// shimEnv used to build shim's variables for plugin's cgroup and rlimits, empty
+// if the plugin doesn't need any shims.  The plugin will not be started when the
+// shim needed but host's main doesn't call RunShim
+func shimEnv(cg *cgroup, limits *process.Limits) ([]string, error) {
+	var env []string
+	if cg != nil {
+		env = append(env, fmt.Sprintf("%s=%s", shimCgroup, cg.path))
+	}
+
+	if limits != nil {
+		if resources := rlimits(limits); len(resources) > 0 {
+			env = append(env, fmt.Sprintf("%s=%s", shimRlimits, formatRlimits(resources)))
+		}
+	}
+
+	if len(env) > 0 && atomic.LoadInt32(&shimReady) == 0 {
+		return nil, fmt.Errorf("%w: driver.RunShim must be called by host's main", errs.ErrPluginLimits)
+	}
+
+	return env, nil
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+// RunShim do nothing, resource limits are only supported on linux
+func RunShim() {}
+
+// shimEnv only supported on linux, the plugin will not be started
+// when it has any limits
+func shimEnv(cg *cgroup, limits *process.Limits) ([]string, error) {
+	if cg == nil && limits.IsEmpty() {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: resource limits are only supported on linux", errs.ErrPluginLimits)
+}
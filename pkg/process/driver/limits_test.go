@@ -0,0 +1,118 @@
+package driver_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+// plugins having limits started by re-executing the test binary as their shim
+func TestMain(m *testing.M) {
+	driver.RunShim()
+	os.Exit(m.Run())
+}
+
+func TestRunShimInheritedVariables(t *testing.T) {
+	// without runner's status pipe, the process must not be taken over
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), "GOPLUGIN_SHIM_EXEC=/bin/false", "GOPLUGIN_SHIM_STATUS=3")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "PASS")
+}
+
+func TestRunSubProcessLimits(t *testing.T) {
+	var core int64
+	sub := driver.NewSubProcess().(process.EnvRunner)
+	env := process.Environment{
+		Limits: &process.Limits{
+			Memory:    1 << 30,
+			CPUTime:   30,
+			OpenFiles: 64,
+			CoreSize:  &core,
+		},
+	}
+
+	// limits applied before the plugin executed, and shim's variables removed
+	script := `echo "$(ulimit -n)|$(ulimit -t)|$(ulimit -c)|$(ulimit -v)|$(env | grep -c GOPLUGIN_SHIM)"`
+	ch, err := sub.RunEnv(env, 0, "test", "sh", 1, "-c", script)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	exit := waitExit(t, plugin)
+	assert.Equal(t, 0, exit.Code)
+	assert.Equal(t, "64|30|0|1048576|0", strings.TrimSpace(plugin.Stdout.String()))
+}
+
+func TestRunPipeProcessLimits(t *testing.T) {
+	sub := driver.NewPipeProcess().(process.EnvRunner)
+	ch, err := sub.RunEnv(process.Environment{
+		Limits: &process.Limits{OpenFiles: 32},
+	}, 0, "test", "sh", 0, "-c", `ulimit -n; sleep 5`)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	defer plugin.Kill()
+
+	line := make([]byte, 64)
+	n, err := plugin.Pipe.Read(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "32", strings.TrimSpace(string(line[:n])))
+}
+
+func TestRunSubProcessCgroupInvalid(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithCgroup(tempDir(t))).(process.EnvRunner)
+	ch, err := sub.RunEnv(process.Environment{
+		Limits: &process.Limits{Memory: 1 << 30},
+	}, 0, "test", "sh", 1, "-c", "exit 0")
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginLimits))
+	assert.Nil(t, ch)
+}
+
+func TestRunSubProcessCgroupSkipped(t *testing.T) {
+	// rlimits only plugin doesn't need any cgroups
+	sub := driver.NewSubProcess(driver.WithCgroup(tempDir(t))).(process.EnvRunner)
+	ch, err := sub.RunEnv(process.Environment{
+		Limits: &process.Limits{OpenFiles: 64},
+	}, 0, "test", "sh", 1, "-c", "exit 0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, waitExit(t, <-ch).Code)
+}
+
+func TestRunSubProcessCgroupFiles(t *testing.T) {
+	// a fake cgroup directory, the process can't be created inside it
+	// but plugin's cgroup files should have been written
+	dir := tempDir(t)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cgroup.controllers"), []byte("cpu memory pids"), 0644))
+
+	sub := driver.NewSubProcess(driver.WithCgroup(dir)).(process.EnvRunner)
+	_, err := sub.RunEnv(process.Environment{
+		Limits: &process.Limits{Memory: 1 << 30, CPU: 0.5, Processes: 16},
+	}, 0, "test", "sh", 1, "-c", "exit 0")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginLimits))
+	assert.Contains(t, err.Error(), "cgroup.procs")
+
+	read := func(path ...string) string {
+		b, err := ioutil.ReadFile(filepath.Join(append([]string{dir}, path...)...))
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "+memory +cpu +pids", read("cgroup.subtree_control"))
+	assert.Equal(t, "1073741824", read("test", "memory.max"))
+	assert.Equal(t, "50000 100000", read("test", "cpu.max"))
+	assert.Equal(t, "16", read("test", "pids.max"))
+}
@@ -0,0 +1,178 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/quadroops/goplugin/internal/utils"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+// pipe used to join plugin's stdin and stdout as a single connection
+type pipe struct {
+	io.Reader
+	io.Writer
+
+	stdin  *os.File
+	stdout *os.File
+}
+
+// Close used to close host's side of plugin's stdin and stdout
+func (p *pipe) Close() error {
+	errIn := p.stdin.Close()
+	errOut := p.stdout.Close()
+	if errIn != nil {
+		return errIn
+	}
+
+	return errOut
+}
+
+func newPipe() (*pipe, *os.File, *os.File, error) {
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
+		return nil, nil, nil, err
+	}
+
+	p := &pipe{
+		Reader: stdoutReader,
+		Writer: stdinWriter,
+		stdin:  stdinWriter,
+		stdout: stdoutReader,
+	}
+
+	return p, stdinReader, stdoutWriter, nil
+}
+
+type pipeRunner struct {
+	hooks     []LaunchHook
+	resolvers map[string]process.SecretResolver
+	cgroupDir string
+}
+
+// NewPipeProcess used to create new instance that implement Runner, plugin will be
+// started without any port and the host will talk to the plugin through its stdin
+// and stdout.  The connection will be available from process.Plugin's Pipe, while
+// plugin's Stdout will always be empty.  Only launch hooks, secret resolvers and
+// cgroup's directory will be used from given options
+func NewPipeProcess(opts ...SubProcessOption) process.Runner {
+	r := &runner{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return &pipeRunner{hooks: r.hooks, resolvers: r.resolvers, cgroupDir: r.cgroupDir}
+}
+
+func (r *pipeRunner) Run(toWait int, name, command string, port int, args ...string) (<-chan process.Plugin, error) {
+	return r.RunEnv(process.Environment{}, toWait, name, command, port, args...)
+}
+
+// RunEnv implement process.EnvRunner
+func (r *pipeRunner) RunEnv(environment process.Environment, toWait int, name, command string, port int, args ...string) (<-chan process.Plugin, error) {
+	var output, stderr utils.Buffer
+
+	environ, err := buildEnv(environment, r.resolvers)
+	if err != nil {
+		return nil, err
+	}
+
+	cg, err := newCgroup(r.cgroupDir, name, environment.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	env, release, err := runHooks(r.hooks, name)
+	if err != nil {
+		cg.remove()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Stderr = &stderr
+
+	cmd.Env = append(environ, env...)
+	cmd.Dir = environment.Workdir
+
+	// given files to the command directly, so the host's side of the pipes
+	// will not be closed by cmd.Wait
+	conn, stdin, stdout, err := newPipe()
+	if err != nil {
+		release()
+		cg.remove()
+		cancel()
+		return nil, fmt.Errorf("%q: %w", err.Error(), errs.ErrPluginCannotStart)
+	}
+
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+
+	err = startLimited(cmd, cg, environment.Limits)
+
+	// plugin's side of the pipes owned by the process now
+	stdin.Close()
+	stdout.Close()
+
+	if err != nil {
+		conn.Close()
+		release()
+		cg.remove()
+		cancel() // manually cancel the context and kill the process
+		return nil, startError(err)
+	}
+
+	// the pipes are ready once the process started, no need to wait
+	// plugin's exec time
+	started := time.Now()
+	exited := make(chan struct{})
+	exitCh := make(chan process.Exit, 1)
+	kill := groupKill(cmd.Process.Pid, cancel)
+	ch := make(chan process.Plugin)
+	go func() {
+		plugin := process.Plugin{
+			Kill:   kill,
+			Stop:   groupStop(cmd.Process.Pid, exited, kill),
+			Exited: exitCh,
+			ID:     process.ID(cmd.Process.Pid),
+			Name:   name,
+			Stderr: &stderr,
+			Stdout: &output,
+			Pipe:   conn,
+		}
+
+		ch <- plugin
+		close(ch)
+	}()
+
+	go func() {
+		err := cmd.Wait()
+		if err != nil {
+			log.Printf("Error wait: %v", err)
+		}
+
+		conn.Close()
+		release()
+		cg.remove()
+		exitCh <- newExit(name, cmd, started, &stderr)
+		close(exitCh)
+		close(exited)
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,33 @@
+package driver_test
+
+import (
+	"bufio"
+	"testing"
+
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPipeProcess(t *testing.T) {
+	sub := driver.NewPipeProcess()
+	process, err := sub.Run(5, "test", "sh", 0, "-c", `read line; echo "$line"`)
+	assert.NoError(t, err)
+
+	plugin := <-process
+	assert.NotNil(t, plugin.Pipe)
+	defer plugin.Kill()
+
+	_, err = plugin.Pipe.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+
+	line, err := bufio.NewReader(plugin.Pipe).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", line)
+}
+
+func TestRunPipeProcessUnknownCommand(t *testing.T) {
+	sub := driver.NewPipeProcess()
+	process, err := sub.Run(0, "test", "unknown", 0)
+	assert.Error(t, err)
+	assert.Nil(t, process)
+}
@@ -0,0 +1,74 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/quadroops/goplugin/internal/utils"
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// EnvPortFile used as environment variable's name to pass a file path, a plugin
+	// started on port 0 must write its listening address to this file
+	EnvPortFile = "GOPLUGIN_PORT_FILE"
+
+	// portFilePollInterval used as waiting time between port file's checks
+	portFilePollInterval = 50 * time.Millisecond
+)
+
+func newPortFile(name string) (string, error) {
+	cookie, err := newCookie()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("goplugin-%s-%s.port", name, cookie[:8])), nil
+}
+
+func portFileEnv(path string) []string {
+	return []string{fmt.Sprintf("%s=%s", EnvPortFile, path)}
+}
+
+// readPortFile used to read plugin's address from port file, the address
+// must be ended by a new line to make sure it has been written completely
+func readPortFile(path string) (string, bool) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil || !strings.HasSuffix(string(b), "\n") {
+		return "", false
+	}
+
+	return strings.TrimSpace(string(b)), true
+}
+
+func waitPortFile(path string, exited <-chan struct{}, stderr *utils.Buffer, timeout time.Duration) (string, error) {
+	defer os.Remove(path)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(portFilePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if addr, ok := readPortFile(path); ok {
+			return addr, nil
+		}
+
+		select {
+		case <-exited:
+			if addr, ok := readPortFile(path); ok {
+				return addr, nil
+			}
+
+			return "", fmt.Errorf("%w: plugin exited before reporting its address: %q", errs.ErrPluginAddress, stderr.String())
+		case <-timer.C:
+			return "", fmt.Errorf("%w: timeout after %v", errs.ErrPluginAddress, timeout)
+		case <-ticker.C:
+		}
+	}
+}
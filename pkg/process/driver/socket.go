@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quadroops/goplugin/internal/utils"
+	"github.com/quadroops/goplugin/pkg/errs"
+)
+
+const (
+	// EnvUnixSocket used as environment variable's name to pass unix domain
+	// socket's path, plugin must listen on this path instead of a tcp port
+	EnvUnixSocket = "GOPLUGIN_UNIX_SOCKET"
+
+	// UnixSchema used as plugin's address prefix when listening on unix domain socket
+	UnixSchema = "unix://"
+
+	// socketDirMode used to restrict runtime directory to host's user
+	socketDirMode = 0700
+
+	// socketMode used to restrict socket file to host's user
+	socketMode = 0600
+)
+
+// DefaultSocketDir used to get default runtime directory for given host, it will use
+// $XDG_RUNTIME_DIR if exist, otherwise os.TempDir() suffixed by current user id
+func DefaultSocketDir(hostName string) string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "goplugin", hostName)
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("goplugin-%d", os.Getuid()), hostName)
+}
+
+// prepareSocket used to create runtime directory and remove stale socket file
+// left by previous process, it will return plugin's socket path
+func prepareSocket(dir, name string) (string, error) {
+	err := os.MkdirAll(dir, socketDirMode)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", errs.ErrPluginCannotStart, err)
+	}
+
+	// MkdirAll doesn't change existing directory's permission
+	err = os.Chmod(dir, socketDirMode)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", errs.ErrPluginCannotStart, err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.sock", name))
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: %q", errs.ErrPluginCannotStart, err)
+	}
+
+	return path, nil
+}
+
+func socketEnv(path string) []string {
+	return []string{fmt.Sprintf("%s=%s", EnvUnixSocket, path)}
+}
+
+func isSocket(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+func waitSocket(path string, exited <-chan struct{}, stderr *utils.Buffer, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(portFilePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if isSocket(path) {
+			return nil
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("%w: plugin exited before listening on socket: %q", errs.ErrPluginAddress, stderr.String())
+		case <-timer.C:
+			return fmt.Errorf("%w: socket timeout after %v", errs.ErrPluginAddress, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+)
+
+// groupKill used to SIGKILL plugin's whole process group, so its children will not be
+// orphaned, and cancel its command's context.  The group's id can't be reused while any
+// of its processes still alive, so the group can be killed even if the plugin has exited
+func groupKill(pid int, cancel context.CancelFunc) context.CancelFunc {
+	return func() {
+		// plugin started with Setpgid, its process group id equals to its pid, ESRCH
+		// means the whole group has exited
+		syscall.Kill(-pid, syscall.SIGKILL)
+		cancel()
+	}
+}
+
+// groupStop used to send SIGTERM to plugin's process group and wait the plugin to exit
+// until given grace period reached, then the whole group will be killed.  Remaining
+// children will be killed once the plugin exited
+func groupStop(pid int, exited <-chan struct{}, kill context.CancelFunc) process.StopFunc {
+	return func(grace time.Duration) error {
+		select {
+		case <-exited:
+			kill()
+			return nil
+		default:
+		}
+
+		err := syscall.Kill(-pid, syscall.SIGTERM)
+		if err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("%w: %q", errs.ErrPluginStop, err)
+		}
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+
+		select {
+		case <-exited:
+			kill()
+			return nil
+		case <-timer.C:
+			kill()
+			<-exited
+			return fmt.Errorf("%w: killed after %s", errs.ErrPluginStopTimeout, grace)
+		}
+	}
+}
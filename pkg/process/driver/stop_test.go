@@ -0,0 +1,140 @@
+package driver_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+	"github.com/quadroops/goplugin/pkg/process/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+// isRunning used to check process's state, orphaned children may stay as zombies
+// when nobody reaps them
+func isRunning(pid int) bool {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+// childPID used to read plugin's child pid printed on its stdout
+func childPID(t *testing.T, plugin process.Plugin) int {
+	var out string
+	for i := 0; i < 50 && out == ""; i++ {
+		time.Sleep(20 * time.Millisecond)
+		out = strings.TrimSpace(plugin.Stdout.String())
+	}
+
+	pid, err := strconv.Atoi(out)
+	assert.NoError(t, err)
+	return pid
+}
+
+func TestStopSubProcessGroup(t *testing.T) {
+	sub := driver.NewSubProcess()
+	process, err := sub.Run(0, "test", "sh", 1, "-c", "sleep 30 & echo $!; wait")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	child := childPID(t, plugin)
+	assert.True(t, isRunning(child))
+
+	err = plugin.Stop(time.Second)
+	assert.NoError(t, err)
+	assert.False(t, isRunning(int(plugin.ID)))
+	assert.False(t, isRunning(child))
+}
+
+func TestStopSubProcessGroupDetachedChild(t *testing.T) {
+	// the child doesn't hold plugin's stdout, so the plugin exits before its child
+	sub := driver.NewSubProcess()
+	script := `(trap "" TERM; sleep 30) >/dev/null 2>&1 & echo $!; wait`
+	process, err := sub.Run(0, "test", "sh", 1, "-c", script)
+	assert.NoError(t, err)
+
+	plugin := <-process
+	child := childPID(t, plugin)
+	assert.True(t, isRunning(child))
+
+	err = plugin.Stop(time.Second)
+	assert.NoError(t, err)
+	assert.False(t, isRunning(int(plugin.ID)))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, isRunning(child))
+}
+
+func TestStopSubProcessGracePeriod(t *testing.T) {
+	sub := driver.NewSubProcess()
+	process, err := sub.Run(0, "test", "sh", 1, "-c", `trap "" TERM; sleep 30 & echo $!; wait`)
+	assert.NoError(t, err)
+
+	plugin := <-process
+	child := childPID(t, plugin)
+
+	start := time.Now()
+	err = plugin.Stop(200 * time.Millisecond)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginStopTimeout))
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	assert.False(t, isRunning(int(plugin.ID)))
+	assert.False(t, isRunning(child))
+}
+
+func TestKillSubProcessGroup(t *testing.T) {
+	sub := driver.NewSubProcess()
+	process, err := sub.Run(0, "test", "sh", 1, "-c", "sleep 30 & echo $!; wait")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	child := childPID(t, plugin)
+
+	plugin.Kill()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, isRunning(child))
+}
+
+func TestStopPipeProcess(t *testing.T) {
+	sub := driver.NewPipeProcess()
+	process, err := sub.Run(0, "test", "sh", 0, "-c", "sleep 30")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	err = plugin.Stop(time.Second)
+	assert.NoError(t, err)
+	assert.False(t, isRunning(int(plugin.ID)))
+}
+
+func TestRunSubProcessFailedKillGroup(t *testing.T) {
+	pidFile := filepath.Join(tempDir(t), "child.pid")
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{
+		Versions: []int{1},
+		Timeout:  300 * time.Millisecond,
+	}))
+
+	script := fmt.Sprintf("sleep 30 & echo $! > %s; wait", pidFile)
+	process, err := sub.Run(0, "test", "sh", 5, "-c", script)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginHandshake))
+	assert.Nil(t, process)
+
+	out, err := ioutil.ReadFile(pidFile)
+	assert.NoError(t, err)
+
+	child, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	assert.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, isRunning(child))
+}
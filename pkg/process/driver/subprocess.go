@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"strconv"
 	"syscall"
@@ -14,56 +15,276 @@ import (
 	"github.com/quadroops/goplugin/pkg/process"
 )
 
-type runner struct{}
+type runner struct {
+	ephemeralTLS bool
+	handshake    *HandshakeOptions
+	socketDir    string
+	hooks        []LaunchHook
+	resolvers    map[string]process.SecretResolver
+	cgroupDir    string
+}
+
+// SubProcessOption used to customize subprocess runner
+type SubProcessOption func(*runner)
+
+// WithEphemeralTLS used to generate throwaway CA and key pairs each time a plugin
+// launched.  Plugin's materials will be passed through environment variables, and
+// host's materials will be available from process.Plugin's Credentials
+func WithEphemeralTLS() SubProcessOption {
+	return func(r *runner) {
+		r.ephemeralTLS = true
+	}
+}
+
+// WithHandshake used to wait plugin's launch handshake instead of sleeping for exec time.
+// Plugin will receive a random cookie and host's supported protocol versions through
+// environment variables, and must print a single JSON line on its stdout before
+// anything else
+func WithHandshake(opts HandshakeOptions) SubProcessOption {
+	return func(r *runner) {
+		r.handshake = &opts
+	}
+}
+
+// WithUnixSocket used to run plugins listening on unix domain socket in given runtime
+// directory instead of a tcp port.  The directory and the socket file will be restricted
+// to host's user.  Plugin will receive socket's path through -socket argument and
+// environment variable, and must listen on it
+func WithUnixSocket(dir string) SubProcessOption {
+	return func(r *runner) {
+		r.socketDir = dir
+	}
+}
 
 // NewSubProcess used to create new instance that implement Runner
-func NewSubProcess() process.Runner {
-	return &runner{}
+func NewSubProcess(opts ...SubProcessOption) process.Runner {
+	r := &runner{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 func (r *runner) Run(toWait int, name, command string, port int, args ...string) (<-chan process.Plugin, error) {
+	return r.RunEnv(process.Environment{}, toWait, name, command, port, args...)
+}
+
+// RunEnv implement process.EnvRunner, goplugin's own variables will always be
+// passed to the plugin after given environment's variables
+func (r *runner) RunEnv(environment process.Environment, toWait int, name, command string, port int, args ...string) (<-chan process.Plugin, error) {
+	environ, err := buildEnv(environment, r.resolvers)
+	if err != nil {
+		return nil, err
+	}
+
 	var stdout, stderr utils.Buffer
+	var creds *process.Credentials
+	var env []string
+
+	if r.ephemeralTLS {
+		var err error
+		creds, err = GenerateCredentials(name)
+		if err != nil {
+			return nil, err
+		}
+
+		env = append(env, credentialsEnv(creds)...)
+	}
+
+	var cookie string
+	if r.handshake != nil {
+		var err error
+		cookie, err = newCookie()
+		if err != nil {
+			return nil, err
+		}
+
+		env = append(env, handshakeEnv(cookie, r.handshake.Versions)...)
+	}
+
+	var socket string
+	if r.socketDir != "" {
+		var err error
+		socket, err = prepareSocket(r.socketDir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		env = append(env, socketEnv(socket)...)
+	}
+
+	// plugin started on port 0 will report its address through a port file,
+	// unless it already reports the address on its handshake
+	var portFile string
+	if port == 0 && r.handshake == nil && socket == "" {
+		var err error
+		portFile, err = newPortFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		env = append(env, portFileEnv(portFile)...)
+	}
+
+	cg, err := newCgroup(r.cgroupDir, name, environment.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	hookEnv, release, err := runHooks(r.hooks, name)
+	if err != nil {
+		cg.remove()
+		return nil, err
+	}
+
+	env = append(env, hookEnv...)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	args = append(args, "-port", strconv.Itoa(port))
+	if socket != "" {
+		args = append(args, "-socket", socket)
+	} else {
+		args = append(args, "-port", strconv.Itoa(port))
+	}
+
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Start()
+	var hw *handshakeWriter
+	if r.handshake != nil {
+		hw = newHandshakeWriter(&stdout)
+		cmd.Stdout = hw
+	}
+
+	cmd.Env = append(environ, env...)
+	cmd.Dir = environment.Workdir
+
+	err = startLimited(cmd, cg, environment.Limits)
 	if err != nil {
+		release()
+		cg.remove()
 		cancel() // manually cancel the context and kill the process
-		return nil, fmt.Errorf("%q: %w", err.Error(), errs.ErrPluginCannotStart)
+		return nil, startError(err)
 	}
 
-	if toWait > 0 {
+	started := time.Now()
+	exited := make(chan struct{})
+	exitCh := make(chan process.Exit, 1)
+	go func() {
+		err := cmd.Wait()
+		if err != nil {
+			log.Printf("Error wait: %v", err)
+		}
+
+		release()
+		cg.remove()
+		exitCh <- newExit(name, cmd, started, &stderr)
+		close(exitCh)
+		close(exited)
+	}()
+
+	kill := groupKill(cmd.Process.Pid, cancel)
+
+	var handshake *process.Handshake
+	var address string
+	switch {
+	case hw != nil:
+		handshake, err = r.waitHandshake(hw, exited, &stderr, toWait, cookie)
+		if err != nil {
+			kill()
+			return nil, err
+		}
+
+		address = handshake.Address
+		if port == 0 && address == "" && socket == "" {
+			kill()
+			return nil, fmt.Errorf("%w: empty handshake's address", errs.ErrPluginAddress)
+		}
+	case portFile != "":
+		address, err = waitPortFile(portFile, exited, &stderr, waitTimeout(toWait, 0))
+		if err != nil {
+			kill()
+			return nil, err
+		}
+	case socket != "":
+		err = waitSocket(socket, exited, &stderr, waitTimeout(toWait, 0))
+		if err != nil {
+			kill()
+			return nil, err
+		}
+	case toWait > 0:
 		// waiting the process
 		time.Sleep(time.Duration(toWait) * time.Second)
 	}
 
+	if socket != "" {
+		// the socket file created by plugin, we need to make sure only
+		// host's user can connect to the plugin
+		if err = os.Chmod(socket, socketMode); err != nil && !os.IsNotExist(err) {
+			kill()
+			return nil, fmt.Errorf("%w: %q", errs.ErrPluginCannotStart, err)
+		}
+
+		address = fmt.Sprintf("%s%s", UnixSchema, socket)
+	}
+
 	ch := make(chan process.Plugin)
 	go func() {
 		plugin := process.Plugin{
-			Kill:   cancel,
-			ID:     process.ID(cmd.Process.Pid),
-			Name:   name,
-			Stderr: &stderr,
-			Stdout: &stdout,
+			Kill:        kill,
+			Stop:        groupStop(cmd.Process.Pid, exited, kill),
+			Exited:      exitCh,
+			ID:          process.ID(cmd.Process.Pid),
+			Name:        name,
+			Stderr:      &stderr,
+			Stdout:      &stdout,
+			Credentials: creds,
+			Handshake:   handshake,
+			Address:     address,
 		}
 
 		ch <- plugin
 		close(ch)
 	}()
 
-	go func() {
-		// for now we doesn't need to handle an error from process
-		// just log the error message
-		err = cmd.Wait()
-		if err != nil {
-			log.Printf("Error wait: %v", err)
+	return ch, nil
+}
+
+func (r *runner) waitHandshake(hw *handshakeWriter, exited <-chan struct{}, stderr *utils.Buffer, toWait int, cookie string) (*process.Handshake, error) {
+	timeout := waitTimeout(toWait, r.handshake.Timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case line := <-hw.ch:
+		return ParseHandshake(line, cookie, r.handshake.Versions)
+	case <-exited:
+		// plugin may send its handshake right before exited
+		select {
+		case line := <-hw.ch:
+			return ParseHandshake(line, cookie, r.handshake.Versions)
+		default:
 		}
-	}()
 
-	return ch, nil
+		return nil, fmt.Errorf("%w: plugin exited before handshake: %q", errs.ErrPluginHandshake, stderr.String())
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: timeout after %v", errs.ErrPluginHandshake, timeout)
+	}
+}
+
+// waitTimeout used to get waiting time for plugin's report, plugin's exec time
+// will be used first, then given fallback or the default one
+func waitTimeout(toWait int, fallback time.Duration) time.Duration {
+	timeout := time.Duration(toWait) * time.Second
+	if timeout <= 0 {
+		timeout = fallback
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+
+	return timeout
 }
@@ -2,11 +2,17 @@ package driver_test
 
 import (
 	"errors"
+	"io/ioutil"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
+	"github.com/quadroops/goplugin/pkg/errs"
 	"github.com/quadroops/goplugin/pkg/process/driver"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +21,15 @@ var (
 	ErrFinished = errors.New("os: process already finished")
 )
 
+// tempDir used to create a temporary directory removed when the test finished
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "goplugin")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
 func checkProcessExist(id int) bool {
 	p, err := os.FindProcess(id)
 	if err != nil {
@@ -54,3 +69,177 @@ func TestRunSubProcessUnknownCommand(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, process)
 }
+
+func TestRunSubProcessEphemeralTLS(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithEphemeralTLS())
+	process, err := sub.Run(1, "test", "sh", 5, "-c", "printenv GOPLUGIN_TLS_SERVER_CERT")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	assert.NotNil(t, plugin.Credentials)
+	assert.Contains(t, plugin.Stdout.String(), strings.TrimSpace(string(plugin.Credentials.ServerCert)))
+}
+
+func TestRunSubProcessHandshake(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{Versions: []int{1, 2}}))
+	script := `printf '{"cookie":"%s","protocol":"rest","version":2,"network":"tcp","address":"127.0.0.1:5"}\n' "$GOPLUGIN_HANDSHAKE_COOKIE"; echo "$GOPLUGIN_PROTOCOL_VERSIONS"`
+	process, err := sub.Run(0, "test", "sh", 5, "-c", script)
+	assert.NoError(t, err)
+
+	plugin := <-process
+	assert.NotNil(t, plugin.Handshake)
+	assert.Equal(t, "rest", plugin.Handshake.Protocol)
+	assert.Equal(t, 2, plugin.Handshake.Version)
+	assert.Equal(t, "127.0.0.1:5", plugin.Handshake.Address)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "1,2\n", plugin.Stdout.String())
+}
+
+func TestRunSubProcessHandshakeCookieMismatch(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{Versions: []int{1}}))
+	script := `echo '{"cookie":"invalid","protocol":"rest","version":1}'; sleep 5`
+	process, err := sub.Run(0, "test", "sh", 5, "-c", script)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginHandshake))
+	assert.Nil(t, process)
+}
+
+func TestRunSubProcessHandshakeExited(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{Versions: []int{1}}))
+	process, err := sub.Run(0, "test", "sh", 5, "-c", "echo failed >&2; exit 1")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginHandshake))
+	assert.Contains(t, err.Error(), "failed")
+	assert.Nil(t, process)
+}
+
+func TestRunSubProcessHandshakeTimeout(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{
+		Versions: []int{1},
+		Timeout:  100 * time.Millisecond,
+	}))
+
+	process, err := sub.Run(0, "test", "sleep", 5)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginHandshake))
+	assert.Nil(t, process)
+}
+
+func TestRunSubProcessPortFile(t *testing.T) {
+	sub := driver.NewSubProcess()
+	script := `test "$2" = "0" && sleep 0.2 && echo "127.0.0.1:38081" > "$GOPLUGIN_PORT_FILE"; sleep 5`
+	process, err := sub.Run(2, "test", "sh", 0, "-c", script, "sh")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	assert.Equal(t, "127.0.0.1:38081", plugin.Address)
+	plugin.Kill()
+}
+
+func TestRunSubProcessPortFileExited(t *testing.T) {
+	sub := driver.NewSubProcess()
+	process, err := sub.Run(2, "test", "sh", 0, "-c", "exit 1")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginAddress))
+	assert.Nil(t, process)
+}
+
+func TestRunSubProcessHandshakeAddress(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{Versions: []int{1}}))
+	script := `printf '{"cookie":"%s","protocol":"grpc","version":1,"network":"tcp","address":"127.0.0.1:38082"}\n' "$GOPLUGIN_HANDSHAKE_COOKIE"; sleep 5`
+	process, err := sub.Run(0, "test", "sh", 0, "-c", script)
+	assert.NoError(t, err)
+
+	plugin := <-process
+	assert.Equal(t, "127.0.0.1:38082", plugin.Address)
+	plugin.Kill()
+}
+
+func TestRunSubProcessHandshakeEmptyAddress(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithHandshake(driver.HandshakeOptions{Versions: []int{1}}))
+	script := `printf '{"cookie":"%s","protocol":"grpc","version":1}\n' "$GOPLUGIN_HANDSHAKE_COOKIE"; sleep 5`
+	process, err := sub.Run(0, "test", "sh", 0, "-c", script)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginAddress))
+	assert.Nil(t, process)
+}
+
+func TestRunSubProcessUnixSocket(t *testing.T) {
+	dir := filepath.Join(tempDir(t), "host")
+	socket := filepath.Join(dir, "test.sock")
+
+	// act as the plugin's listener, since the process itself can't bind a socket
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		listener, err := net.Listen("unix", socket)
+		if err == nil {
+			defer listener.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	sub := driver.NewSubProcess(driver.WithUnixSocket(dir))
+	process, err := sub.Run(2, "test", "sh", 5, "-c", `test "$1" = "-socket" && sleep 5`, "sh")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	defer plugin.Kill()
+
+	assert.Equal(t, "unix://"+socket, plugin.Address)
+
+	info, err := os.Stat(socket)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	info, err = os.Stat(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestRunSubProcessUnixSocketExited(t *testing.T) {
+	sub := driver.NewSubProcess(driver.WithUnixSocket(tempDir(t)))
+	process, err := sub.Run(2, "test", "sh", 5, "-c", "exit 1")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginAddress))
+	assert.Nil(t, process)
+}
+
+func TestRunSubProcessLaunchHook(t *testing.T) {
+	released := make(chan string, 1)
+	hook := func(name string) ([]string, func(), error) {
+		return []string{"GOPLUGIN_HOOK=" + name}, func() { released <- name }, nil
+	}
+
+	sub := driver.NewSubProcess(driver.WithLaunchHook(hook))
+	process, err := sub.Run(0, "test", "sh", 1, "-c", "printenv GOPLUGIN_HOOK")
+	assert.NoError(t, err)
+
+	plugin := <-process
+	select {
+	case name := <-released:
+		assert.Equal(t, "test", name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("hook has not been released")
+	}
+
+	assert.Equal(t, "test\n", plugin.Stdout.String())
+}
+
+func TestRunSubProcessLaunchHookError(t *testing.T) {
+	released := false
+	first := func(name string) ([]string, func(), error) {
+		return nil, func() { released = true }, nil
+	}
+
+	second := func(name string) ([]string, func(), error) {
+		return nil, nil, errors.New("error")
+	}
+
+	sub := driver.NewSubProcess(driver.WithLaunchHook(first), driver.WithLaunchHook(second))
+	process, err := sub.Run(0, "test", "sleep", 1, "5")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginCannotStart))
+	assert.Nil(t, process)
+	assert.True(t, released)
+}
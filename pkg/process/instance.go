@@ -2,27 +2,56 @@ package process
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/quadroops/goplugin/pkg/errs"
 )
 
-// Instance used to setup new process instance
+// Instance used to setup new process instance.  Runners used to start plugins
+// using spesific protocol, other plugins will be started by main runner
 type Instance struct {
 	runner    Runner
+	runners   map[string]Runner
 	processes ProcessesBuilder
+
+	mutex    sync.Mutex
+	onExit   []OnExit
+	stopping map[ID]bool
 }
 
 // OnError used to catch error
 type OnError func(err error)
 
+// OnExit used to catch plugin's exit status
+type OnExit func(exit Exit)
+
 // New used to create new process instance
 func New(runner Runner, processes ProcessesBuilder) *Instance {
 	return &Instance{
 		runner:    runner,
+		runners:   make(map[string]Runner),
 		processes: processes,
+		stopping:  make(map[ID]bool),
 	}
 }
 
+// OnExit used to register a handler called each time a registered plugin's process
+// exited, the process has been deregistered before the handler called
+func (i *Instance) OnExit(handler OnExit) *Instance {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.onExit = append(i.onExit, handler)
+	return i
+}
+
+// RegisterRunner used to register a runner for plugins using given protocol
+func (i *Instance) RegisterRunner(protocol string, runner Runner) *Instance {
+	i.runners[protocol] = runner
+	return i
+}
+
 // IsReady used to check if requested plugin started or not
 func (i *Instance) IsReady(name string) bool {
 	return i.processes.IsExist(name)
@@ -31,7 +60,60 @@ func (i *Instance) IsReady(name string) bool {
 // RegisterNewProcess put new subprocess to process registry
 func (i *Instance) RegisterNewProcess(plugin <-chan Plugin) error {
 	p := <-plugin
-	return i.processes.Add(p)
+	return i.Register(p)
+}
+
+// Register put a started plugin's process to process registry, the process will
+// be deregistered automatically once exited if its runner reports exit status
+func (i *Instance) Register(plugin Plugin) error {
+	err := i.processes.Add(plugin)
+	if err != nil {
+		return err
+	}
+
+	if plugin.Exited != nil {
+		go i.watch(plugin)
+	}
+
+	return nil
+}
+
+// watch used to wait plugin's exit status, deregister its process and
+// deliver the status to all exit handlers
+func (i *Instance) watch(plugin Plugin) {
+	exit, ok := <-plugin.Exited
+	if !ok {
+		return
+	}
+
+	i.mutex.Lock()
+	exit.Crashed = !i.stopping[plugin.ID]
+	delete(i.stopping, plugin.ID)
+	handlers := make([]OnExit, len(i.onExit))
+	copy(handlers, i.onExit)
+	i.mutex.Unlock()
+
+	// plugin may have been restarted using a new process
+	current, err := i.processes.Get(plugin.Name)
+	if err == nil && current.ID == plugin.ID {
+		i.processes.Remove(plugin.Name)
+	}
+
+	for _, handler := range handlers {
+		handler(exit)
+	}
+}
+
+// markStopping used to mark plugin's process as asked to stop by the host,
+// so its exit status will not be reported as crashed
+func (i *Instance) markStopping(plugin Plugin) {
+	if plugin.Exited == nil {
+		return
+	}
+
+	i.mutex.Lock()
+	i.stopping[plugin.ID] = true
+	i.mutex.Unlock()
 }
 
 // GetProcessID used to get plugin process ID
@@ -44,13 +126,44 @@ func (i *Instance) GetProcessID(pluginName string) (ID, error) {
 	return plugin.ID, nil
 }
 
+// GetPlugin used to get plugin's process information
+func (i *Instance) GetPlugin(pluginName string) (Plugin, error) {
+	return i.processes.Get(pluginName)
+}
+
 // Run used to start new subprocess
 func (i *Instance) Run(toWait int, name, command string, port int, args ...string) (<-chan Plugin, error) {
+	return i.RunProtocol("", toWait, name, command, port, args...)
+}
+
+// RunProtocol used to start new subprocess using runner registered for given protocol,
+// main runner will be used if there is no runner registered
+func (i *Instance) RunProtocol(protocol string, toWait int, name, command string, port int, args ...string) (<-chan Plugin, error) {
+	return i.RunProtocolEnv(protocol, Environment{}, toWait, name, command, port, args...)
+}
+
+// RunProtocolEnv used to start new subprocess like RunProtocol using given environment,
+// the runner must implement EnvRunner unless the environment is empty
+func (i *Instance) RunProtocolEnv(protocol string, env Environment, toWait int, name, command string, port int, args ...string) (<-chan Plugin, error) {
 	if i.processes.IsExist(name) {
 		return nil, fmt.Errorf("%w", errs.ErrPluginStarted)
 	}
 
-	return i.runner.Run(toWait, name, command, port, args...)
+	runner, exist := i.runners[protocol]
+	if !exist {
+		runner = i.runner
+	}
+
+	if env.IsEmpty() {
+		return runner.Run(toWait, name, command, port, args...)
+	}
+
+	envRunner, ok := runner.(EnvRunner)
+	if !ok {
+		return nil, fmt.Errorf("%w: runner doesn't support plugin's environment", errs.ErrPluginEnv)
+	}
+
+	return envRunner.RunEnv(env, toWait, name, command, port, args...)
 }
 
 // Kill used to kill individual plugin's process
@@ -61,6 +174,7 @@ func (i *Instance) Kill(name string) error {
 	}
 
 	if plugin.Name != "" {
+		i.markStopping(plugin)
 		plugin.Kill()
 	}
 
@@ -68,26 +182,73 @@ func (i *Instance) Kill(name string) error {
 	return nil
 }
 
-// KillAll used to kill all available plugin's processes
-func (i *Instance) KillAll() []error {
-	var errors []error
+// Stop used to gracefully stop individual plugin's process, plugin will be killed
+// if it's still running after given grace period
+func (i *Instance) Stop(name string, grace time.Duration) error {
+	plugin, err := i.processes.Get(name)
+	if err != nil {
+		return err
+	}
+
+	i.markStopping(plugin)
+	err = stop(plugin, grace)
+	i.processes.Remove(name)
+	return err
+}
+
+// KillAll used to gracefully stop all available plugin's processes in parallel,
+// it will return each plugin's stop result mapped by plugin's name
+func (i *Instance) KillAll(grace time.Duration) (map[string]error, error) {
 	observer, err := i.processes.Listen()
 	if err != nil {
-		errors = append(errors, err)
-		return errors
+		return nil, err
 	}
 
+	var plugins []Plugin
+	var castErr error
 	<-observer.DoOnNext(func(val interface{}) {
 		plugin, ok := val.(Plugin)
 		if !ok {
-			errors = append(errors, errs.ErrCastInterface)
-		} else {
-			plugin.Kill()
+			castErr = errs.ErrCastInterface
+			return
 		}
+
+		i.markStopping(plugin)
+		plugins = append(plugins, plugin)
 	})
 
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error)
+	for _, plugin := range plugins {
+		wg.Add(1)
+		go func(plugin Plugin) {
+			defer wg.Done()
+
+			err := stop(plugin, grace)
+			mutex.Lock()
+			results[plugin.Name] = err
+			mutex.Unlock()
+		}(plugin)
+	}
+
+	wg.Wait()
+
 	// after kill all processes, we need to make sure
 	// current processes is empty
 	i.processes.Reset()
-	return errors
+	return results, castErr
+}
+
+func stop(plugin Plugin, grace time.Duration) error {
+	if plugin.Name == "" {
+		return nil
+	}
+
+	if plugin.Stop != nil {
+		return plugin.Stop(grace)
+	}
+
+	plugin.Kill()
+	return nil
 }
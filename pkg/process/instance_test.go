@@ -3,7 +3,9 @@ package process_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -52,6 +54,38 @@ func TestRunSuccess(t *testing.T) {
 	assert.Equal(t, plugin.Name, "test")
 }
 
+func TestRunProtocolRegisteredRunner(t *testing.T) {
+	runner := new(mocks.Runner)
+	stdioRunner := new(mocks.Runner)
+	stdioRunner.On("Run", 1, "test", "test", 0).Once().Return(createMockChanPlugin(createMockPlugin("test")), nil)
+
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("IsExist", "test").Once().Return(false)
+
+	p := process.New(runner, processes).RegisterRunner("stdio", stdioRunner)
+	ch, err := p.RunProtocol("stdio", 1, "test", "test", 0)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	assert.Equal(t, plugin.Name, "test")
+	runner.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunProtocolMainRunner(t *testing.T) {
+	runner := new(mocks.Runner)
+	runner.On("Run", 1, "test", "test", 1001).Once().Return(createMockChanPlugin(createMockPlugin("test")), nil)
+
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("IsExist", "test").Once().Return(false)
+
+	p := process.New(runner, processes).RegisterRunner("stdio", new(mocks.Runner))
+	ch, err := p.RunProtocol("rest", 1, "test", "test", 1001)
+	assert.NoError(t, err)
+
+	plugin := <-ch
+	assert.Equal(t, plugin.Name, "test")
+}
+
 func TestGetProcessID(t *testing.T) {
 	payload := createMockProcessID(createMockPlugin("test"), 1001)
 
@@ -144,8 +178,10 @@ func TestKillAllSuccess(t *testing.T) {
 	processes.On("Reset").Once().Return(nil)
 
 	p := process.New(runner, processes)
-	errs := p.KillAll()
-	assert.Len(t, errs, 0)
+	results, err := p.KillAll(time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.NoError(t, results["test"])
 }
 
 func TestKillAllError(t *testing.T) {
@@ -155,8 +191,83 @@ func TestKillAllError(t *testing.T) {
 	processes.On("Reset").Once().Return(nil)
 
 	p := process.New(runner, processes)
-	errs := p.KillAll()
-	assert.Len(t, errs, 1)
+	results, err := p.KillAll(time.Second)
+	assert.Error(t, err)
+	assert.Len(t, results, 0)
+}
+
+func TestKillAllStopResults(t *testing.T) {
+	var stopped []time.Duration
+	var mutex sync.Mutex
+
+	plugin1 := createMockPlugin("test1")
+	plugin1.Stop = func(grace time.Duration) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		stopped = append(stopped, grace)
+		return nil
+	}
+
+	plugin2 := createMockPlugin("test2")
+	plugin2.Stop = func(grace time.Duration) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		stopped = append(stopped, grace)
+		return errs.ErrPluginStopTimeout
+	}
+
+	obs := rxgo.Just(plugin1, plugin2)()
+
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("Listen").Once().Return(obs, nil)
+	processes.On("Reset").Once().Return(nil)
+
+	p := process.New(runner, processes)
+	results, err := p.KillAll(time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NoError(t, results["test1"])
+	assert.True(t, errors.Is(results["test2"], errs.ErrPluginStopTimeout))
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, stopped)
+}
+
+func TestStopSuccess(t *testing.T) {
+	var grace time.Duration
+	plugin := createMockPlugin("test")
+	plugin.Stop = func(d time.Duration) error {
+		grace = d
+		return nil
+	}
+
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("Get", "test").Once().Return(plugin, nil)
+	processes.On("Remove", "test").Once().Return(nil)
+
+	p := process.New(runner, processes)
+	err := p.Stop("test", 2*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, grace)
+	processes.AssertExpectations(t)
+}
+
+func TestStopWithoutStopFunc(t *testing.T) {
+	var killed bool
+	plugin := createMockPlugin("test")
+	plugin.Kill = func() { killed = true }
+
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("Get", "test").Once().Return(plugin, nil)
+	processes.On("Remove", "test").Once().Return(nil)
+
+	p := process.New(runner, processes)
+	err := p.Stop("test", time.Second)
+	assert.NoError(t, err)
+	assert.True(t, killed)
 }
 
 func TestRegisterNewProcess(t *testing.T) {
@@ -172,3 +283,124 @@ func TestRegisterNewProcess(t *testing.T) {
 	err := p.RegisterNewProcess(pluginCh)
 	assert.NoError(t, err)
 }
+
+func TestRegisterExitedCrashed(t *testing.T) {
+	exited := make(chan process.Exit, 1)
+	plugin := createMockProcessID(createMockPlugin("test"), 10)
+	plugin.Exited = exited
+
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("Add", mock.Anything).Once().Return(nil)
+	processes.On("Get", "test").Once().Return(plugin, nil)
+	processes.On("Remove", "test").Once().Return(nil)
+
+	received := make(chan process.Exit, 1)
+	p := process.New(runner, processes).OnExit(func(exit process.Exit) {
+		received <- exit
+	})
+
+	err := p.Register(plugin)
+	assert.NoError(t, err)
+
+	exited <- process.Exit{Name: "test", ID: 10, Code: 1}
+	close(exited)
+
+	select {
+	case exit := <-received:
+		assert.Equal(t, 1, exit.Code)
+		assert.True(t, exit.Crashed)
+	case <-time.After(time.Second):
+		t.Fatal("exit status not received")
+	}
+
+	processes.AssertExpectations(t)
+}
+
+func TestRegisterExitedRestarted(t *testing.T) {
+	exited := make(chan process.Exit, 1)
+	plugin := createMockProcessID(createMockPlugin("test"), 10)
+	plugin.Exited = exited
+
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("Add", mock.Anything).Once().Return(nil)
+	processes.On("Get", "test").Once().Return(createMockProcessID(createMockPlugin("test"), 11), nil)
+
+	received := make(chan process.Exit, 1)
+	p := process.New(runner, processes).OnExit(func(exit process.Exit) {
+		received <- exit
+	})
+
+	err := p.Register(plugin)
+	assert.NoError(t, err)
+
+	exited <- process.Exit{Name: "test", ID: 10}
+	close(exited)
+
+	<-received
+	processes.AssertNotCalled(t, "Remove", "test")
+}
+
+func TestKillExitedNotCrashed(t *testing.T) {
+	exited := make(chan process.Exit, 1)
+	plugin := createMockProcessID(createMockPlugin("test"), 10)
+	plugin.Exited = exited
+	plugin.Kill = func() {
+		exited <- process.Exit{Name: "test", ID: 10, Code: -1, Signal: "killed"}
+		close(exited)
+	}
+
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("Add", mock.Anything).Once().Return(nil)
+	processes.On("Get", "test").Return(plugin, nil)
+	processes.On("Remove", "test").Return(nil)
+
+	received := make(chan process.Exit, 1)
+	p := process.New(runner, processes).OnExit(func(exit process.Exit) {
+		received <- exit
+	})
+
+	err := p.Register(plugin)
+	assert.NoError(t, err)
+
+	err = p.Kill("test")
+	assert.NoError(t, err)
+
+	select {
+	case exit := <-received:
+		assert.Equal(t, "killed", exit.Signal)
+		assert.False(t, exit.Crashed)
+	case <-time.After(time.Second):
+		t.Fatal("exit status not received")
+	}
+}
+
+func TestRunProtocolEnvUnsupported(t *testing.T) {
+	runner := new(mocks.Runner)
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("IsExist", "test").Once().Return(false)
+
+	p := process.New(runner, processes)
+	ch, err := p.RunProtocolEnv("", process.Environment{Clear: true}, 1, "test", "test", 1001)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrPluginEnv))
+	assert.Nil(t, ch)
+	runner.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunProtocolEnvEmpty(t *testing.T) {
+	plugin := createMockChanPlugin(createMockPlugin("test"))
+
+	runner := new(mocks.Runner)
+	runner.On("Run", 1, "test", "test", 1001).Once().Return(plugin, nil)
+
+	processes := new(mocks.ProcessesBuilder)
+	processes.On("IsExist", "test").Once().Return(false)
+
+	p := process.New(runner, processes)
+	ch, err := p.RunProtocolEnv("", process.Environment{}, 1, "test", "test", 1001)
+	assert.NoError(t, err)
+	assert.NotNil(t, ch)
+}
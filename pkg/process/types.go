@@ -2,6 +2,8 @@ package process
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/quadroops/goplugin/internal/utils"
 	"github.com/reactivex/rxgo/v2"
@@ -10,13 +12,69 @@ import (
 // ID is an alias for os PID
 type ID int
 
-// Plugin used when running a plugin to save their state and process id information
+// DefaultGracePeriod used as default waiting time for a plugin to exit after
+// asked to stop, before it's killed
+const DefaultGracePeriod = 5 * time.Second
+
+// StopFunc used to gracefully stop plugin's process, it should kill the plugin
+// if it's still running after given grace period
+type StopFunc func(grace time.Duration) error
+
+// Credentials used to store ephemeral TLS materials generated for a single plugin's launch,
+// all certificates and keys are PEM encoded.  Server's materials will be passed to the plugin,
+// and client's materials will be used by the host
+type Credentials struct {
+	CACert     []byte
+	ServerCert []byte
+	ServerKey  []byte
+	ClientCert []byte
+	ClientKey  []byte
+}
+
+// Handshake used to store plugin's launch handshake, sent by the plugin
+// as a single JSON line on its stdout
+type Handshake struct {
+	Cookie       string   `json:"cookie"`
+	Protocol     string   `json:"protocol"`
+	Version      int      `json:"version"`
+	Network      string   `json:"network"`
+	Address      string   `json:"address"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// Exit used to store plugin's exit status, sent by the runner once plugin's process
+// exited.  Code will be -1 if the process has been terminated by a signal, and Stderr
+// only contains the tail of plugin's stderr.  Crashed will be true if the process
+// exited without asked to stop by the host
+type Exit struct {
+	Name    string
+	ID      ID
+	Code    int
+	Signal  string
+	Runtime time.Duration
+	Stderr  string
+	Crashed bool
+}
+
+// Plugin used when running a plugin to save their state and process id information.
+// Credentials will be nil if runner doesn't generate ephemeral TLS materials, and
+// Handshake will be nil if runner doesn't use launch handshake.  Address will be
+// filled by plugin's reported address when started on port 0.  Pipe will be filled
+// by plugin's stdin and stdout when started by pipe runner.  Stop will be nil if the
+// runner doesn't support graceful stop, Kill will be used instead.  Exited will
+// receive plugin's exit status once, if supported by the runner
 type Plugin struct {
-	Kill   context.CancelFunc
-	Name   string
-	ID     ID
-	Stdout *utils.Buffer
-	Stderr *utils.Buffer
+	Kill        context.CancelFunc
+	Stop        StopFunc
+	Name        string
+	ID          ID
+	Stdout      *utils.Buffer
+	Stderr      *utils.Buffer
+	Credentials *Credentials
+	Handshake   *Handshake
+	Address     string
+	Pipe        io.ReadWriteCloser
+	Exited      <-chan Exit
 }
 
 // ProcessesBuilder is main interface to manipulate list of available processes
@@ -56,3 +114,53 @@ type RegistryBuilder interface {
 type Runner interface {
 	Run(toWait int, name, execCommand string, port int, args ...string) (<-chan Plugin, error)
 }
+
+// Environment used to configure plugin's process environment.  Plugin will inherit
+// host's environment unless Clear is true, only Passthrough variables will be inherited
+// in that case.  Files used to read variable's value from a file, and Secrets used as
+// secret references resolved by runner's secret resolvers, using "<scheme>:<ref>"
+// format.  Resolved values will only be passed to the plugin and never logged.
+// Limits used to restrict plugin's process resources
+type Environment struct {
+	Env         map[string]string
+	Passthrough []string
+	Files       map[string]string
+	Secrets     map[string]string
+	Clear       bool
+	Workdir     string
+	Limits      *Limits
+}
+
+// IsEmpty used to check if the environment doesn't change anything from host's
+func (e Environment) IsEmpty() bool {
+	return len(e.Env) < 1 && len(e.Passthrough) < 1 && len(e.Files) < 1 &&
+		len(e.Secrets) < 1 && !e.Clear && e.Workdir == "" && e.Limits.IsEmpty()
+}
+
+// Limits used to restrict plugin's process resources, zero values will not be limited.
+// Memory (bytes), CPUTime (seconds), OpenFiles, Processes and CoreSize (bytes) applied
+// as process's rlimits, CoreSize 0 used to disable core dumps.  Memory, Processes and
+// CPU (number of CPUs) also applied to plugin's cgroup if the runner uses cgroups
+type Limits struct {
+	Memory    int64
+	CPUTime   int64
+	OpenFiles int64
+	Processes int64
+	CoreSize  *int64
+	CPU       float64
+}
+
+// IsEmpty used to check if there is no resource to limit
+func (l *Limits) IsEmpty() bool {
+	return l == nil || (l.Memory < 1 && l.CPUTime < 1 && l.OpenFiles < 1 &&
+		l.Processes < 1 && l.CoreSize == nil && l.CPU <= 0)
+}
+
+// SecretResolver used to resolve a secret reference into its value
+type SecretResolver func(ref string) (string, error)
+
+// EnvRunner used as an optional interface implemented by runners able to start
+// plugins using their own environment and working directory
+type EnvRunner interface {
+	RunEnv(env Environment, toWait int, name, execCommand string, port int, args ...string) (<-chan Plugin, error)
+}
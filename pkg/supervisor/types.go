@@ -1,9 +1,15 @@
 package supervisor
 
-// Payload used as main data when some plugin from some host indicated as error / cannot be reached
+import "github.com/quadroops/goplugin/pkg/process"
+
+// Payload used as main data when some plugin from some host indicated as error / cannot be reached.
+// Err used to store the error's cause, and Exit used to store plugin's exit status when its process
+// has been crashed
 type Payload struct {
 	Host   string
 	Plugin string
+	Err    error
+	Exit   *process.Exit
 }
 
 // OnErrorHandler used as main type for handling plugin's error
@@ -49,6 +49,36 @@ func (_m *PluginClient) Exec(ctx context.Context, in *plugin.ExecRequest, opts .
 	return r0, r1
 }
 
+// ExecStream provides a mock function with given fields: ctx, in, opts
+func (_m *PluginClient) ExecStream(ctx context.Context, in *plugin.ExecRequest, opts ...grpc.CallOption) (plugin.Plugin_ExecStreamClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 plugin.Plugin_ExecStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, *plugin.ExecRequest, ...grpc.CallOption) plugin.Plugin_ExecStreamClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(plugin.Plugin_ExecStreamClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *plugin.ExecRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Ping provides a mock function with given fields: ctx, in, opts
 func (_m *PluginClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*plugin.PingResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -78,3 +108,33 @@ func (_m *PluginClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grp
 
 	return r0, r1
 }
+
+// Session provides a mock function with given fields: ctx, opts
+func (_m *PluginClient) Session(ctx context.Context, opts ...grpc.CallOption) (plugin.Plugin_SessionClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 plugin.Plugin_SessionClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) plugin.Plugin_SessionClient); ok {
+		r0 = rf(ctx, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(plugin.Plugin_SessionClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -289,6 +289,108 @@ func (x *ExecResponse) GetData() *DataRPC {
 	return nil
 }
 
+type ExecChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
+}
+
+func (x *ExecChunk) Reset() {
+	*x = ExecChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plugin_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecChunk) ProtoMessage() {}
+
+func (x *ExecChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecChunk.ProtoReflect.Descriptor instead.
+func (*ExecChunk) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExecChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SessionMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Command string `protobuf:"bytes,1,opt,name=Command,proto3" json:"Command,omitempty"`
+	Data    []byte `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+}
+
+func (x *SessionMessage) Reset() {
+	*x = SessionMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plugin_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SessionMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionMessage) ProtoMessage() {}
+
+func (x *SessionMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionMessage.ProtoReflect.Descriptor instead.
+func (*SessionMessage) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SessionMessage) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *SessionMessage) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 var File_plugin_proto protoreflect.FileDescriptor
 
 var file_plugin_proto_rawDesc = []byte{
@@ -313,15 +415,29 @@ var file_plugin_proto_rawDesc = []byte{
 	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x74, 0x61,
 	0x74, 0x75, 0x73, 0x12, 0x23, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x52,
-	0x50, 0x43, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x32, 0x71, 0x0a, 0x06, 0x50, 0x6c, 0x75, 0x67,
-	0x69, 0x6e, 0x12, 0x34, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
-	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
-	0x74, 0x79, 0x1a, 0x14, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x50, 0x69, 0x6e, 0x67,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x04, 0x45, 0x78, 0x65, 0x63,
-	0x12, 0x13, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45,
-	0x78, 0x65, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0a, 0x5a, 0x08, 0x2e,
-	0x3b, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x50, 0x43, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x22, 0x1f, 0x0a, 0x09, 0x45, 0x78, 0x65, 0x63,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x22, 0x3e, 0x0a, 0x0e, 0x53, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x43,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x43, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x32, 0xe8, 0x01, 0x0a, 0x06, 0x50, 0x6c,
+	0x75, 0x67, 0x69, 0x6e, 0x12, 0x34, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x14, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x50, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x04, 0x45, 0x78,
+	0x65, 0x63, 0x12, 0x13, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x78, 0x65, 0x63,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x2e, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a,
+	0x0a, 0x45, 0x78, 0x65, 0x63, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x13, 0x2e, 0x70, 0x6c,
+	0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x11, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x43, 0x68,
+	0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x3d, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x16, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x28, 0x01, 0x30, 0x01, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x3b, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -336,24 +452,30 @@ func file_plugin_proto_rawDescGZIP() []byte {
 	return file_plugin_proto_rawDescData
 }
 
-var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_plugin_proto_goTypes = []interface{}{
-	(*Data)(nil),         // 0: plugin.Data
-	(*DataRPC)(nil),      // 1: plugin.DataRPC
-	(*PingResponse)(nil), // 2: plugin.PingResponse
-	(*ExecRequest)(nil),  // 3: plugin.ExecRequest
-	(*ExecResponse)(nil), // 4: plugin.ExecResponse
-	(*empty.Empty)(nil),  // 5: google.protobuf.Empty
+	(*Data)(nil),           // 0: plugin.Data
+	(*DataRPC)(nil),        // 1: plugin.DataRPC
+	(*PingResponse)(nil),   // 2: plugin.PingResponse
+	(*ExecRequest)(nil),    // 3: plugin.ExecRequest
+	(*ExecResponse)(nil),   // 4: plugin.ExecResponse
+	(*ExecChunk)(nil),      // 5: plugin.ExecChunk
+	(*SessionMessage)(nil), // 6: plugin.SessionMessage
+	(*empty.Empty)(nil),    // 7: google.protobuf.Empty
 }
 var file_plugin_proto_depIdxs = []int32{
 	0, // 0: plugin.PingResponse.Data:type_name -> plugin.Data
 	1, // 1: plugin.ExecResponse.Data:type_name -> plugin.DataRPC
-	5, // 2: plugin.Plugin.Ping:input_type -> google.protobuf.Empty
+	7, // 2: plugin.Plugin.Ping:input_type -> google.protobuf.Empty
 	3, // 3: plugin.Plugin.Exec:input_type -> plugin.ExecRequest
-	2, // 4: plugin.Plugin.Ping:output_type -> plugin.PingResponse
-	4, // 5: plugin.Plugin.Exec:output_type -> plugin.ExecResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
+	3, // 4: plugin.Plugin.ExecStream:input_type -> plugin.ExecRequest
+	6, // 5: plugin.Plugin.Session:input_type -> plugin.SessionMessage
+	2, // 6: plugin.Plugin.Ping:output_type -> plugin.PingResponse
+	4, // 7: plugin.Plugin.Exec:output_type -> plugin.ExecResponse
+	5, // 8: plugin.Plugin.ExecStream:output_type -> plugin.ExecChunk
+	6, // 9: plugin.Plugin.Session:output_type -> plugin.SessionMessage
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
 	2, // [2:2] is the sub-list for extension type_name
 	2, // [2:2] is the sub-list for extension extendee
 	0, // [0:2] is the sub-list for field type_name
@@ -425,6 +547,30 @@ func file_plugin_proto_init() {
 				return nil
 			}
 		}
+		file_plugin_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plugin_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SessionMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -432,7 +578,7 @@ func file_plugin_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_plugin_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -460,6 +606,8 @@ const _ = grpc.SupportPackageIsVersion6
 type PluginClient interface {
 	Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PingResponse, error)
 	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (*ExecResponse, error)
+	ExecStream(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (Plugin_ExecStreamClient, error)
+	Session(ctx context.Context, opts ...grpc.CallOption) (Plugin_SessionClient, error)
 }
 
 type pluginClient struct {
@@ -488,10 +636,75 @@ func (c *pluginClient) Exec(ctx context.Context, in *ExecRequest, opts ...grpc.C
 	return out, nil
 }
 
+func (c *pluginClient) ExecStream(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (Plugin_ExecStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[0], "/plugin.Plugin/ExecStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginExecStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Plugin_ExecStreamClient interface {
+	Recv() (*ExecChunk, error)
+	grpc.ClientStream
+}
+
+type pluginExecStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginExecStreamClient) Recv() (*ExecChunk, error) {
+	m := new(ExecChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pluginClient) Session(ctx context.Context, opts ...grpc.CallOption) (Plugin_SessionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[1], "/plugin.Plugin/Session", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginSessionClient{stream}
+	return x, nil
+}
+
+type Plugin_SessionClient interface {
+	Send(*SessionMessage) error
+	Recv() (*SessionMessage, error)
+	grpc.ClientStream
+}
+
+type pluginSessionClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginSessionClient) Send(m *SessionMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pluginSessionClient) Recv() (*SessionMessage, error) {
+	m := new(SessionMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // PluginServer is the server API for Plugin service.
 type PluginServer interface {
 	Ping(context.Context, *empty.Empty) (*PingResponse, error)
 	Exec(context.Context, *ExecRequest) (*ExecResponse, error)
+	ExecStream(*ExecRequest, Plugin_ExecStreamServer) error
+	Session(Plugin_SessionServer) error
 }
 
 // UnimplementedPluginServer can be embedded to have forward compatible implementations.
@@ -504,6 +717,12 @@ func (*UnimplementedPluginServer) Ping(context.Context, *empty.Empty) (*PingResp
 func (*UnimplementedPluginServer) Exec(context.Context, *ExecRequest) (*ExecResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Exec not implemented")
 }
+func (*UnimplementedPluginServer) ExecStream(*ExecRequest, Plugin_ExecStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExecStream not implemented")
+}
+func (*UnimplementedPluginServer) Session(Plugin_SessionServer) error {
+	return status.Errorf(codes.Unimplemented, "method Session not implemented")
+}
 
 func RegisterPluginServer(s *grpc.Server, srv PluginServer) {
 	s.RegisterService(&_Plugin_serviceDesc, srv)
@@ -545,6 +764,53 @@ func _Plugin_Exec_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Plugin_ExecStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PluginServer).ExecStream(m, &pluginExecStreamServer{stream})
+}
+
+type Plugin_ExecStreamServer interface {
+	Send(*ExecChunk) error
+	grpc.ServerStream
+}
+
+type pluginExecStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginExecStreamServer) Send(m *ExecChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Plugin_Session_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PluginServer).Session(&pluginSessionServer{stream})
+}
+
+type Plugin_SessionServer interface {
+	Send(*SessionMessage) error
+	Recv() (*SessionMessage, error)
+	grpc.ServerStream
+}
+
+type pluginSessionServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginSessionServer) Send(m *SessionMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pluginSessionServer) Recv() (*SessionMessage, error) {
+	m := new(SessionMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _Plugin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "plugin.Plugin",
 	HandlerType: (*PluginServer)(nil),
@@ -558,6 +824,18 @@ var _Plugin_serviceDesc = grpc.ServiceDesc{
 			Handler:    _Plugin_Exec_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecStream",
+			Handler:       _Plugin_ExecStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Session",
+			Handler:       _Plugin_Session_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "plugin.proto",
 }
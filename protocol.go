@@ -1,8 +1,20 @@
 package goplugin
 
 import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
 	"github.com/quadroops/goplugin/pkg/caller"
 	"github.com/quadroops/goplugin/pkg/caller/driver"
+	"github.com/quadroops/goplugin/pkg/discover"
+	"github.com/quadroops/goplugin/pkg/errs"
+	"github.com/quadroops/goplugin/pkg/process"
+	driverProcess "github.com/quadroops/goplugin/pkg/process/driver"
 )
 
 // BuildProtocol a helper to check and also generate a default
@@ -12,20 +24,227 @@ func BuildProtocol(opt *ProtocolOption) caller.Builder {
 		return nil
 	}
 
-	// if both of configurations is not defined then return nil
+	// if all of configurations is not defined then return nil
 	// no need to continue
-	if opt.GRPCOpts == nil && opt.RESTOpts == nil {
+	if opt.GRPCOpts == nil && opt.RESTOpts == nil && opt.StdioOpts == nil && opt.Options == nil {
 		return nil
 	}
 
 	return func(commType string, port int) caller.Caller {
-		switch commType {
-		case "rest":
-			return driver.NewREST(opt.RESTOpts)
-		case "grpc":
-			return driver.NewGRPC(opt.GRPCOpts)
+		c, err := caller.NewProtocol(commType, opt.Get(commType))
+		if err != nil {
+			log.Printf("Error building protocol: %v", err)
+			return nil
 		}
 
+		return c
+	}
+}
+
+// Get used to get options for given protocol, builtin protocols will use
+// their own options, other protocols will use Options
+func (o *ProtocolOption) Get(protocolType string) interface{} {
+	switch protocolType {
+	case driver.ProtocolREST:
+		return o.RESTOpts
+	case driver.ProtocolGRPC:
+		return o.GRPCOpts
+	case driver.ProtocolStdio:
+		return o.StdioOpts
+	}
+
+	return o.Options
+}
+
+// closeProtocol used to release protocol's resources such as
+// shared client connections, all configured options will be closed
+func closeProtocol(opt *ProtocolOption) error {
+	if opt == nil {
+		return nil
+	}
+
+	var errGroups error
+	if opt.GRPCOpts != nil {
+		if err := opt.GRPCOpts.Close(); err != nil {
+			errGroups = multierror.Append(errGroups, err)
+		}
+	}
+
+	if opt.RESTOpts != nil {
+		if err := opt.RESTOpts.Close(); err != nil {
+			errGroups = multierror.Append(errGroups, err)
+		}
+	}
+
+	if opt.StdioOpts != nil {
+		if err := opt.StdioOpts.Close(); err != nil {
+			errGroups = multierror.Append(errGroups, err)
+		}
+	}
+
+	return errGroups
+}
+
+// applyPluginTLS used to apply plugin's tls configurations from config file
+// only if protocol's tls options has not been defined
+func applyPluginTLS(opt *ProtocolOption, conf *discover.PluginTLS) {
+	if opt == nil || conf == nil {
+		return
+	}
+
+	tlsOpts := &driver.TLSOptions{
+		CAFile:     conf.CAFile,
+		CertFile:   conf.CertFile,
+		KeyFile:    conf.KeyFile,
+		ServerName: conf.ServerName,
+	}
+
+	if opt.RESTOpts != nil && opt.RESTOpts.GetTLS() == nil {
+		opt.RESTOpts.SetTLS(tlsOpts)
+	}
+
+	if opt.GRPCOpts != nil && opt.GRPCOpts.GetTLS() == nil {
+		opt.GRPCOpts.SetTLS(tlsOpts)
+	}
+}
+
+// applyCredentials used to pin protocol's tls options to plugin's ephemeral
+// credentials, it will override any tls options defined before
+func applyCredentials(opt *ProtocolOption, creds *process.Credentials) error {
+	if opt == nil || creds == nil {
 		return nil
 	}
+
+	var pinned *driver.PinnedTLS
+	if opt.RESTOpts != nil {
+		if tlsOpts := opt.RESTOpts.GetTLS(); tlsOpts != nil {
+			pinned = tlsOpts.Pinned
+		}
+	}
+
+	if pinned == nil && opt.GRPCOpts != nil {
+		if tlsOpts := opt.GRPCOpts.GetTLS(); tlsOpts != nil {
+			pinned = tlsOpts.Pinned
+		}
+	}
+
+	if pinned == nil {
+		pinned = driver.NewPinnedTLS()
+		tlsOpts := &driver.TLSOptions{Pinned: pinned}
+
+		if opt.RESTOpts != nil {
+			opt.RESTOpts.SetTLS(tlsOpts)
+		}
+
+		if opt.GRPCOpts != nil {
+			opt.GRPCOpts.SetTLS(tlsOpts)
+		}
+	}
+
+	return pinned.Update(creds.ClientCert, creds.ClientKey, creds.ServerCert)
+}
+
+// applyPipe used to attach plugin's stdin and stdout to stdio's options
+func applyPipe(opt *ProtocolOption, conn io.ReadWriter) {
+	if opt == nil || opt.StdioOpts == nil || conn == nil {
+		return
+	}
+
+	opt.StdioOpts.Attach(conn)
+}
+
+// protocolPort used to get configured port based on plugin's protocol type,
+// port 0 means plugin should bind any free port and report it back
+func protocolPort(opt *ProtocolOption, protocolType string) int {
+	if opt == nil {
+		return 0
+	}
+
+	return caller.ProtocolPort(opt.Get(protocolType))
+}
+
+// protocolAddress used to get the address called by plugin's caller, formatted as
+// host:port or unix:// prefixed socket's path
+func protocolAddress(opt *ProtocolOption, protocolType string) string {
+	if opt == nil {
+		return ""
+	}
+
+	return caller.ProtocolAddress(opt.Get(protocolType))
+}
+
+// applyAddress used to point protocol's options to plugin's reported address,
+// given address can be formatted as host:port, :port, only the port or
+// unix:// prefixed socket's path
+func applyAddress(opt *ProtocolOption, address string) error {
+	if opt == nil || address == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(address, driverProcess.UnixSchema) {
+		socket := strings.TrimPrefix(address, driverProcess.UnixSchema)
+		if opt.RESTOpts != nil {
+			opt.RESTOpts.SetSocket(socket)
+		}
+
+		if opt.GRPCOpts != nil {
+			opt.GRPCOpts.SetSocket(socket)
+		}
+
+		return nil
+	}
+
+	host, strPort, err := net.SplitHostPort(address)
+	if err != nil {
+		host, strPort = "", address
+	}
+
+	port, err := strconv.Atoi(strPort)
+	if err != nil || port <= 0 {
+		return fmt.Errorf("%w: invalid address %q", errs.ErrPluginAddress, address)
+	}
+
+	if opt.RESTOpts != nil {
+		addr := opt.RESTOpts.Addr
+		if host != "" {
+			// keep configured schema such as https://
+			schema := ""
+			if i := strings.Index(addr, "://"); i >= 0 {
+				schema = addr[:i+3]
+			}
+
+			addr = fmt.Sprintf("%s%s", schema, host)
+		}
+
+		opt.RESTOpts.SetAddress(addr, port)
+	}
+
+	if opt.GRPCOpts != nil {
+		addr := opt.GRPCOpts.Addr
+		if host != "" {
+			addr = host
+		}
+
+		opt.GRPCOpts.SetAddress(addr, port)
+	}
+
+	return nil
+}
+
+// runPort used to get the port given to plugin's process, a plugin configured
+// with port 0 will always be started on port 0 including when restarted
+func (c *PluginConf) runPort(protocolType string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	port := protocolPort(c.Protocol, protocolType)
+	if port == 0 {
+		c.dynamicPort = true
+	}
+
+	if c.dynamicPort {
+		return 0
+	}
+
+	return port
 }
@@ -1,13 +1,17 @@
 package goplugin
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 
 	"github.com/quadroops/goplugin/pkg/process"
 
 	"github.com/hashicorp/go-multierror"
 
+	"github.com/quadroops/goplugin/pkg/callback"
 	"github.com/quadroops/goplugin/pkg/caller"
 	"github.com/quadroops/goplugin/pkg/errs"
 	"github.com/quadroops/goplugin/pkg/executor"
@@ -37,12 +41,21 @@ func (r *Registry) Install(options *InstallationOptions) (*Registry, error) {
 			hosts = append(hosts, host)
 			reg := executor.Register(host, h.GetProcessInstance())
 			registries = append(registries, reg)
+
+			if h.callbacks != nil {
+				h.callbacks.WithPluginCalls(r.CallPlugin)
+				if h.events != nil {
+					h.callbacks.WithEvents(&pluginEvents{r})
+				}
+			}
 		}
 	}
 
 	r.hosts = hosts
 	r.exec = executor.New(&executor.Options{
 		RetryTimeout: options.RetryTimeoutCaller,
+		RetryPolicy:  options.RetryPolicy,
+		Breaker:      options.Breaker,
 	}, registries...)
 
 	// setup all hosts
@@ -51,9 +64,33 @@ func (r *Registry) Install(options *InstallationOptions) (*Registry, error) {
 		return nil, err
 	}
 
+	err = r.validateProtocols()
+	if err != nil {
+		return nil, err
+	}
+
 	return r, nil
 }
 
+// validateProtocols used to make sure all installed plugins are using registered protocols,
+// custom protocols must be registered before installing the hosts
+func (r *Registry) validateProtocols() error {
+	var errGroups error
+	for _, h := range r.hosts {
+		container, err := r.exec.FromHost(h.Hostname)
+		if err != nil {
+			return err
+		}
+
+		err = container.ValidateProtocols()
+		if err != nil {
+			errGroups = multierror.Append(errGroups, fmt.Errorf("%s: %w", h.Hostname, err))
+		}
+	}
+
+	return errGroups
+}
+
 // GetAllPlugins used to get all plugins from all hosts
 func (r *Registry) GetAllPlugins() ([]*HostPlugins, error) {
 	var hostPlugins []*HostPlugins
@@ -153,22 +190,33 @@ func (r *Registry) GetCaller(host, plugin string) (*caller.Plugin, error) {
 		return nil, err
 	}
 
-	var port int
-	if meta.ProtocolType == "rest" {
-		port = pluginConf.Protocol.RESTOpts.Port
-	} else {
-		port = pluginConf.Protocol.GRPCOpts.Port
+	// if plugin not ready yet, we need to run it
+	started := !container.IsPluginReady(plugin)
+	if started {
+		err = container.Run(plugin, pluginConf.runPort(meta.ProtocolType))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// if plugin not ready yet, we need to run it
-	if !container.IsPluginReady(plugin) {
-		err = container.Run(plugin, port)
+	// plugin started on port 0 has reported its own port
+	port, err := r.applyProcess(hostPlugin, plugin, pluginConf, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if started {
+		address := protocolAddress(pluginConf.Protocol, meta.ProtocolType)
+		err = container.WaitReady(plugin, address, port, BuildProtocol(pluginConf.Protocol))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	p, err := container.Get(plugin, port, BuildProtocol(pluginConf.Protocol))
+	p, err := container.GetWithOptions(plugin, port, BuildProtocol(pluginConf.Protocol), &executor.PluginOptions{
+		RetryPolicy: pluginConf.RetryPolicy,
+		Breaker:     pluginConf.Breaker,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -176,15 +224,127 @@ func (r *Registry) GetCaller(host, plugin string) (*caller.Plugin, error) {
 	return p, nil
 }
 
-// KillPlugins used to kill all plugins from all installed hosts
-func (r *Registry) KillPlugins() {
-	if len(r.hostPlugins) >= 1 {
-		for _, host := range r.hostPlugins {
-			log.Printf("Killing all plugins from host: %s ...", host.hostName)
-			h := host.GetProcessInstance()
-			h.KillAll()
+// CallPlugin used to call plugin's command on behalf of other plugin from the same host,
+// the call must be allowed by host's calls and must not create a loop on its call chain
+func (r *Registry) CallPlugin(ctx context.Context, host, from, plugin, cmd string, payload []byte) ([]byte, error) {
+	builder, err := r.getHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if !builder.CanCall(from, plugin) {
+		return nil, fmt.Errorf("%w: %s -> %s", errs.ErrPluginCallDenied, from, plugin)
+	}
+
+	chain := append(append([]string{}, callback.CallChain(ctx)...), from)
+	for _, name := range chain {
+		if name == plugin {
+			return nil, fmt.Errorf("%w: %s -> %s", errs.ErrPluginCallLoop, strings.Join(chain, " -> "), plugin)
 		}
 	}
+
+	p, err := r.GetCaller(host, plugin)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ExecContext(callback.WithCallChain(ctx, chain), cmd, payload)
+}
+
+func (r *Registry) getHost(name string) (*host.Builder, error) {
+	for _, h := range r.hosts {
+		if h.Hostname == name {
+			return h, nil
+		}
+	}
+
+	return nil, errs.ErrNoHosts
+}
+
+// GetBreakerState used to get plugin's circuit breaker state, a plugin without
+// circuit breaker will always be closed
+func (r *Registry) GetBreakerState(host, plugin string) (caller.BreakerState, error) {
+	container, err := r.GetContainer(host)
+	if err != nil {
+		return caller.BreakerClosed, err
+	}
+
+	return container.BreakerState(plugin), nil
+}
+
+// applyProcess used to point plugin's caller to the address or pipes reported by the
+// plugin and pin it to ephemeral credentials generated when the plugin launched, if any.
+// Plugin's tls configurations will be applied too, it returns the port used by the caller.
+// Protocol's options shared by running callers, so they're only changed one at a time
+func (r *Registry) applyProcess(hostPlugin *GoPlugin, plugin string, pluginConf *PluginConf, meta *host.Registry) (int, error) {
+	proc, err := hostPlugin.GetProcessInstance().GetPlugin(plugin)
+	if err != nil {
+		return 0, err
+	}
+
+	pluginConf.mutex.Lock()
+	defer pluginConf.mutex.Unlock()
+
+	applyPluginTLS(pluginConf.Protocol, meta.TLS)
+	err = applyAddress(pluginConf.Protocol, proc.Address)
+	if err != nil {
+		return 0, err
+	}
+
+	applyPipe(pluginConf.Protocol, proc.Pipe)
+
+	err = applyCredentials(pluginConf.Protocol, proc.Credentials)
+	if err != nil {
+		return 0, err
+	}
+
+	return protocolPort(pluginConf.Protocol, meta.ProtocolType), nil
+}
+
+// KillPlugins used to gracefully stop all plugins from all installed hosts in parallel,
+// plugins still running after their host's grace period will be killed.  It will return
+// each plugin's stop result mapped by host's and plugin's names
+func (r *Registry) KillPlugins() map[string]map[string]error {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]map[string]error)
+
+	for _, host := range r.hostPlugins {
+		wg.Add(1)
+		go func(host *GoPlugin) {
+			defer wg.Done()
+
+			log.Printf("Stopping all plugins from host: %s ...", host.hostName)
+			h := host.GetProcessInstance()
+			stopped, err := h.KillAll(host.gracePeriod)
+			if err != nil {
+				log.Printf("Error stopping plugins from host: %s, %v", host.hostName, err)
+			}
+
+			for name, err := range stopped {
+				if err != nil {
+					log.Printf("Error stopping plugin: %s, %v", name, err)
+				}
+			}
+
+			if host.events != nil {
+				host.events.Close()
+			}
+
+			for name, conf := range host.hostPlugins {
+				if err := closeProtocol(conf.Protocol); err != nil {
+					log.Printf("Error closing plugin's connection: %s, %v", name, err)
+				}
+			}
+
+			mutex.Lock()
+			results[host.hostName] = stopped
+			mutex.Unlock()
+		}(host)
+	}
+
+	wg.Wait()
+	return results
 }
 
 // GetPID used to get plugin's process id
@@ -8,8 +8,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/quadroops/goplugin/pkg/caller"
 	"github.com/quadroops/goplugin/pkg/errs"
 	"github.com/quadroops/goplugin/pkg/host"
+	"github.com/quadroops/goplugin/pkg/process"
 	"github.com/quadroops/goplugin/pkg/supervisor"
 )
 
@@ -89,6 +91,11 @@ func (s *PluginSupervisor) Watch() <-chan *supervisor.Payload {
 	s.ticker = time.NewTicker(time.Duration(s.interval) * time.Second)
 	payloadChan := make(chan *supervisor.Payload)
 
+	// crashed plugins will be handled immediately without waiting the ticker
+	for _, hostPlugin := range s.hostPlugins {
+		s.watchExit(hostPlugin, payloadChan)
+	}
+
 	// put the process in the background
 	go func() {
 		for {
@@ -110,7 +117,9 @@ func (s *PluginSupervisor) Watch() <-chan *supervisor.Payload {
 				// by all registered error handlers
 				for _, hostPlugin := range s.hostPlugins {
 					for plugin := range hostPlugin.Plugins {
-						go func(plugin host.PluginName) {
+						// loop variables are shared by all iterations, the goroutine
+						// must not capture them
+						go func(hostPlugin *HostPlugins, plugin host.PluginName) {
 							pluginName := string(plugin)
 
 							// sending ping request
@@ -118,15 +127,16 @@ func (s *PluginSupervisor) Watch() <-chan *supervisor.Payload {
 
 							// when an error triggered, we need to check if current error
 							// allowed to send to channel
-							if err != nil && errors.Is(err, errs.ErrEmptyProcesses) {
+							if err != nil && (errors.Is(err, errs.ErrEmptyProcesses) || errors.Is(err, errs.ErrPluginCircuitOpen)) {
 								payload := supervisor.Payload{
 									Host:   hostPlugin.Host,
 									Plugin: pluginName,
+									Err:    err,
 								}
 
 								payloadChan <- &payload
 							}
-						}(plugin)
+						}(hostPlugin, plugin)
 					}
 				}
 			}
@@ -136,6 +146,33 @@ func (s *PluginSupervisor) Watch() <-chan *supervisor.Payload {
 	return payloadChan
 }
 
+// watchExit used to trigger an error's event each time host's plugin crashed
+func (s *PluginSupervisor) watchExit(hostPlugin *HostPlugins, payloadChan chan<- *supervisor.Payload) {
+	hostInstance, err := s.pluggable.GetHostPluginInstance(hostPlugin.Host)
+	if err != nil {
+		log.Printf("Error getting host: %v", err)
+		return
+	}
+
+	hostInstance.GetProcessInstance().OnExit(func(exit process.Exit) {
+		// process instance may be shared between hosts
+		if _, exist := hostPlugin.Plugins[host.PluginName(exit.Name)]; !exist || !exit.Crashed {
+			return
+		}
+
+		payload := supervisor.Payload{
+			Host:   hostPlugin.Host,
+			Plugin: exit.Name,
+			Err:    fmt.Errorf("%w: code %d, signal %q: %q", errs.ErrPluginExited, exit.Code, exit.Signal, exit.Stderr),
+			Exit:   &exit,
+		}
+
+		go func() {
+			payloadChan <- &payload
+		}()
+	})
+}
+
 // OnError implement supervisor.Driver interface
 func (s *PluginSupervisor) OnError(event *supervisor.Payload, handlers ...supervisor.OnErrorHandler) {
 	if len(handlers) >= 1 {
@@ -166,12 +203,21 @@ func (s *PluginSupervisor) AutoRestart(payload *supervisor.Payload) {
 		return
 	}
 
-	log.Printf("Killing plugin's process...")
-	process := hostInstance.GetProcessInstance()
-	err = process.Kill(payload.Plugin)
+	// crashed plugin's process has been exited and deregistered
+	if payload.Exit == nil {
+		log.Printf("Killing plugin's process...")
+		process := hostInstance.GetProcessInstance()
+		err = process.Kill(payload.Plugin)
+		if err != nil {
+			log.Println("Killing plugin's process")
+			return
+		}
+	}
+
+	// old connection should not be reused by restarted process
+	err = closeProtocol(pluginConf.Protocol)
 	if err != nil {
-		log.Println("Killing plugin's process")
-		return
+		log.Printf("Error closing plugin's connection: %v", err)
 	}
 
 	meta, err := container.GetPluginMeta(payload.Plugin)
@@ -180,19 +226,29 @@ func (s *PluginSupervisor) AutoRestart(payload *supervisor.Payload) {
 		return
 	}
 
-	var port int
-	if meta.ProtocolType == "rest" {
-		port = pluginConf.Protocol.RESTOpts.Port
-	} else {
-		port = pluginConf.Protocol.GRPCOpts.Port
-	}
-
 	log.Println("Restarting plugin's process")
-	err = container.Run(payload.Plugin, port)
+	err = container.Run(payload.Plugin, pluginConf.runPort(meta.ProtocolType))
 	if err != nil {
 		log.Printf("Error restarting plugin's process: %v", err)
 		return
 	}
+
+	// restarted plugin may use new address and ephemeral credentials
+	port, err := s.pluggable.applyProcess(hostInstance, payload.Plugin, pluginConf, meta)
+	if err != nil {
+		log.Printf("Error applying plugin's process: %v", err)
+		return
+	}
+	address := protocolAddress(pluginConf.Protocol, meta.ProtocolType)
+	err = container.WaitReady(payload.Plugin, address, port, BuildProtocol(pluginConf.Protocol))
+	if err != nil {
+		log.Printf("Error waiting plugin's readiness: %v", err)
+		return
+	}
+
+	// plugin's process has been restarted, no need to wait
+	// circuit breaker's cool-down period
+	container.ResetBreaker(payload.Plugin)
 }
 
 // Shutdown should be used on defer's way, it will should be automatically
@@ -223,5 +279,14 @@ func (s *PluginSupervisor) checkPlugin(host, plugin string) error {
 		return fmt.Errorf("%w: %q", errs.ErrEmptyProcesses, err)
 	}
 
+	state, err := s.pluggable.GetBreakerState(host, plugin)
+	if err != nil {
+		return err
+	}
+
+	if state == caller.BreakerOpen {
+		return errs.ErrPluginCircuitOpen
+	}
+
 	return nil
 }
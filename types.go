@@ -1,23 +1,31 @@
 package goplugin
 
 import (
+	"sync"
 	"time"
 
+	"github.com/quadroops/goplugin/pkg/callback"
+	"github.com/quadroops/goplugin/pkg/caller"
 	"github.com/quadroops/goplugin/pkg/caller/driver"
 	"github.com/quadroops/goplugin/pkg/discover"
+	"github.com/quadroops/goplugin/pkg/event"
 	"github.com/quadroops/goplugin/pkg/executor"
 	"github.com/quadroops/goplugin/pkg/host"
 	"github.com/quadroops/goplugin/pkg/process"
+	driverProcess "github.com/quadroops/goplugin/pkg/process/driver"
 	"github.com/quadroops/goplugin/pkg/supervisor"
 )
 
-// ProtocolOption used to configure rest or grpc options
-// You have to choose between rest or grpc, if your plugin
-// using rest, than ignore grpc option, and vice versa, but you
-// can't ignore them both
+// ProtocolOption used to configure rest, grpc or stdio options
+// You have to choose between rest, grpc or stdio, if your plugin
+// using rest, than ignore other options, and vice versa, but you
+// can't ignore them all.  Options used for protocols registered
+// by caller.RegisterProtocol
 type ProtocolOption struct {
-	RESTOpts *driver.RESTOptions
-	GRPCOpts *driver.GrpcOptions
+	RESTOpts  *driver.RESTOptions
+	GRPCOpts  *driver.GrpcOptions
+	StdioOpts *driver.StdioOptions
+	Options   interface{}
 }
 
 // GoPlugin used as main struct to store goplugin's state
@@ -28,22 +36,39 @@ type GoPlugin struct {
 	configParser    *discover.ConfigParser
 	processInstance *process.Instance
 	identityChecker host.IdentityChecker
+	runnerOptions   []driverProcess.SubProcessOption
+	callbacks       *callback.Services
+	events          *event.Bus
+	gracePeriod     time.Duration
 }
 
 // Option used to customize default objects
 type Option func(*GoPlugin)
 
-// InstallationOptions used to store any options on install
+// InstallationOptions used to store any options on install.  RetryPolicy will be
+// used as global caller's retry policy, if it's not defined, RetryTimeoutCaller
+// will be used as initial interval of default retry policy.  Breaker used as global
+// circuit breaker's options, circuit breaker will be disabled if it's not defined
 type InstallationOptions struct {
 	RetryTimeoutCaller int
+	RetryPolicy        *caller.RetryPolicy
+	Breaker            *caller.BreakerOptions
 }
 
 // PluginMapper used as registry to store plugin's config
 type PluginMapper map[string]*PluginConf
 
-// PluginConf used to store plugin's configurations
+// PluginConf used to store plugin's configurations.  RetryPolicy and Breaker
+// used to override global retry policy and circuit breaker for this plugin.
+// If protocol's port is 0, the plugin will bind any free port and report it back
 type PluginConf struct {
-	Protocol *ProtocolOption
+	Protocol    *ProtocolOption
+	RetryPolicy *caller.RetryPolicy
+	Breaker     *caller.BreakerOptions
+
+	// protocol's options shared by all plugin's callers
+	mutex       sync.Mutex
+	dynamicPort bool
 }
 
 // Registry used as wrapper of executor object